@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"tunnel-monitor/internal/alerts"
+
+	"github.com/spf13/cobra"
+)
+
+var alertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "管理 Grafana 告警",
+	Long:  "将仓库中维护的告警规则同步到 Grafana",
+}
+
+var alertsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "同步告警规则",
+	Long:  "读取 alerts 目录中的 YAML 规则，通过 Grafana 告警配置 API 同步到托管规则组，仓库中已删除的规则会从 Grafana 中删除",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return alerts.Sync()
+	},
+}
+
+func init() {
+	alertsCmd.AddCommand(alertsSyncCmd)
+	rootCmd.AddCommand(alertsCmd)
+}
@@ -12,12 +12,14 @@ var dashboardCmd = &cobra.Command{
 	Long:  "创建和管理 Grafana 监控面板",
 }
 
+var createOpts dashboard.CreateOptions
+
 var createBusinessCmd = &cobra.Command{
 	Use:   "create",
 	Short: "创建IPTunnel业务监控面板",
 	Long:  "创建业务监控面板，包含客户端和服务端所有指标，支持按带宽线路筛选",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return dashboard.CreateBusinessDashboard()
+		return dashboard.CreateDashboard("business", createOpts)
 	},
 }
 
@@ -26,16 +28,55 @@ var createServerCmd = &cobra.Command{
 	Short: "创建IPTunnel服务端监控面板",
 	Long:  "创建服务端监控面板，专注于服务端健康状态、通信状态和业务统计",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return dashboard.CreateServerDashboard()
+		return dashboard.CreateDashboard("server", createOpts)
+	},
+}
+
+var createClientCmd = &cobra.Command{
+	Use:   "create-client",
+	Short: "创建IPTunnel客户端监控面板",
+	Long:  "创建客户端监控面板，只包含 POP 客户端上报的 Prometheus 指标，支持按POP机器和用户机器筛选",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.CreateDashboard("client", createOpts)
+	},
+}
+
+var createDatabaseCmd = &cobra.Command{
+	Use:   "create-database",
+	Short: "创建tunnel_server数据库监控面板",
+	Long:  "创建 tunnel_server 所用 MySQL 的监控面板，包含连接数、慢查询和 QPS（需启用 features.MySQL）",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.CreateDashboard("database", createOpts)
+	},
+}
+
+var popDetailsOpts dashboard.PopDetailsOptions
+
+var createPopDetailsCmd = &cobra.Command{
+	Use:   "create-pop-details",
+	Short: "为每个POP创建详情面板",
+	Long:  "从 MySQL 的 machines 表或 Prometheus 的 exported_instance 发现 POP，按 dashboards/manifests/pop-detail.yaml 为每个 POP 创建一个锁定该 POP 的面板，并删除已下线 POP 的面板",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.CreatePopDetailDashboards(popDetailsOpts)
+	},
+}
+
+var createFromCmd = &cobra.Command{
+	Use:   "create-from <manifest>",
+	Short: "按面板清单创建面板",
+	Long:  "按 dashboards/manifests/<manifest>.yaml 中声明的基础模板、面板片段和行创建面板",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.CreateDashboard(args[0], createOpts)
 	},
 }
 
 var createAllCmd = &cobra.Command{
 	Use:   "create-all",
 	Short: "创建所有监控面板",
-	Long:  "创建业务监控面板和服务端监控面板",
+	Long:  "按 dashboards/manifests 目录中的所有面板清单创建面板",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return dashboard.CreateAllDashboards()
+		return dashboard.CreateAllDashboards(createOpts)
 	},
 }
 
@@ -48,12 +89,143 @@ var listCmd = &cobra.Command{
 	},
 }
 
+var pullOpts dashboard.PullOptions
+
+var pullCmd = &cobra.Command{
+	Use:   "pull <uid>",
+	Short: "从 Grafana 拉取面板到模板",
+	Long:  "拉取 Grafana 中的面板，还原数据源占位符后拆分为基础模板和panels片段，便于提交在 Grafana 中所做的修改",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.PullDashboard(args[0], pullOpts)
+	},
+}
+
+var importFileOpts dashboard.ImportFileOptions
+
+var importFileCmd = &cobra.Command{
+	Use:   "import-file <path>",
+	Short: "将 Grafana 导出的面板文件导入到模板",
+	Long:  "规范化从 Grafana 界面导出的面板 JSON（移除 __inputs、__requires，还原数据源占位符），拆分为基础模板、ASCII 文件名的panels片段和面板清单",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.ImportDashboardFile(args[0], importFileOpts)
+	},
+}
+
+var historyLimit int
+
+var historyCmd = &cobra.Command{
+	Use:   "history <uid>",
+	Short: "查看面板版本历史",
+	Long:  "列出 Grafana 中面板的历史版本，包括版本号、修改人、时间和版本说明",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.ShowDashboardHistory(args[0], historyLimit)
+	},
+}
+
+var rollbackVersion int
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <uid>",
+	Short: "恢复面板到指定版本",
+	Long:  "将 Grafana 中的面板恢复到指定的历史版本",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.RollbackDashboard(args[0], rollbackVersion)
+	},
+}
+
+var lintCmd = &cobra.Command{
+	Use:          "lint",
+	Short:        "检查面板模板",
+	Long:         "检查所有模板、panels片段和面板清单：JSON 格式、未定义的变量、未知的数据源占位符、重复的 panel ID 和没有 panel 的 row，发现问题时以非零状态退出",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.LintDashboards()
+	},
+}
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "管理内置模板",
+	Long:  "内置模板随二进制文件发布，模板目录（dashboards.dir）不存在时使用",
+}
+
+var (
+	extractOut   string
+	extractForce bool
+)
+
+var templatesExtractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "导出内置模板",
+	Long:  "将内置的基础模板、面板清单和panels片段导出到目录，修改后将 dashboards.dir 指向该目录即可使用",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.ExtractTemplates(extractOut, extractForce)
+	},
+}
+
+var renderOpts dashboard.RenderOptions
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "渲染 Grafana provisioning 文件",
+	Long:  "将所有面板渲染为完整的 dashboard JSON，并生成 dashboards.yaml 和 datasources.yaml，供 Grafana 启动时从 /etc/grafana/provisioning 加载",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dashboard.RenderProvisioning(renderOpts)
+	},
+}
+
 func init() {
+	for _, c := range []*cobra.Command{createBusinessCmd, createServerCmd, createClientCmd, createDatabaseCmd, createFromCmd, createAllCmd} {
+		c.Flags().BoolVar(&createOpts.DryRun, "dry-run", false, "只渲染面板并与 Grafana 中的线上版本比较，不做修改")
+		c.Flags().BoolVar(&createOpts.LibraryPanels, "library-panels", false, "将panels片段发布为库面板，面板中引用库面板（也可在配置中设置 dashboards.library_panels）")
+		c.Flags().BoolVar(&createOpts.Lenient, "lenient", false, "跳过无法加载的panels片段并输出警告（默认遇到错误即停止）")
+		c.Flags().StringVar(&createOpts.Env, "env", "", "环境名称，应用 overlays/<env> 中的覆盖配置（如 staging）")
+	}
+
+	createPopDetailsCmd.Flags().BoolVar(&popDetailsOpts.DryRun, "dry-run", false, "只渲染面板并与 Grafana 中的线上版本比较，列出将删除的面板，不做修改")
+	createPopDetailsCmd.Flags().BoolVar(&popDetailsOpts.LibraryPanels, "library-panels", false, "将panels片段发布为库面板，面板中引用库面板（也可在配置中设置 dashboards.library_panels）")
+	createPopDetailsCmd.Flags().BoolVar(&popDetailsOpts.Lenient, "lenient", false, "跳过无法加载的panels片段并输出警告（默认遇到错误即停止）")
+	createPopDetailsCmd.Flags().StringVar(&popDetailsOpts.Env, "env", "", "环境名称，应用 overlays/<env> 中的覆盖配置（如 staging）")
+	createPopDetailsCmd.Flags().StringVar(&popDetailsOpts.Source, "source", "", "POP 来源：mysql（machines 表）或 prometheus（exported_instance），默认启用 features.MySQL 时为 mysql")
+
+	pullCmd.Flags().StringVar(&pullOpts.Name, "name", "", "面板清单名称；没有对应清单时输出到 dashboards/<name>-base.json 和 dashboards/panels/<name>")
+	pullCmd.Flags().StringVar(&pullOpts.BasePath, "base", "", "基础模板输出路径")
+	pullCmd.Flags().StringVar(&pullOpts.PanelsDir, "panels-dir", "", "panels片段输出目录")
+	importFileCmd.Flags().StringVar(&importFileOpts.Name, "name", "", "模板名称，输出到 dashboards/<name>-base.json、dashboards/panels/<name> 和 dashboards/manifests/<name>.yaml")
+	importFileCmd.MarkFlagRequired("name")
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 20, "最多显示的版本数")
+	rollbackCmd.Flags().IntVar(&rollbackVersion, "version", 0, "要恢复的版本号")
+	rollbackCmd.MarkFlagRequired("version")
+	renderCmd.Flags().StringVar(&renderOpts.OutDir, "out", "", "输出目录")
+	renderCmd.Flags().StringVar(&renderOpts.DashboardsPath, "dashboards-path", "", "Grafana 主机上存放 dashboard JSON 的目录（默认为输出目录下 dashboards 的绝对路径）")
+	renderCmd.Flags().BoolVar(&renderOpts.Lenient, "lenient", false, "跳过无法加载的panels片段并输出警告（默认遇到错误即停止）")
+	renderCmd.Flags().StringVar(&renderOpts.Env, "env", "", "环境名称，应用 overlays/<env> 中的覆盖配置（如 staging）")
+	renderCmd.MarkFlagRequired("out")
+
+	templatesExtractCmd.Flags().StringVar(&extractOut, "out", "./dashboards", "输出目录")
+	templatesExtractCmd.Flags().BoolVar(&extractForce, "force", false, "覆盖已存在的文件")
+	templatesCmd.AddCommand(templatesExtractCmd)
+
 	// 主要命令
 	dashboardCmd.AddCommand(createBusinessCmd)
 	dashboardCmd.AddCommand(createServerCmd)
+	dashboardCmd.AddCommand(createClientCmd)
+	dashboardCmd.AddCommand(createDatabaseCmd)
+	dashboardCmd.AddCommand(createPopDetailsCmd)
+	dashboardCmd.AddCommand(createFromCmd)
 	dashboardCmd.AddCommand(createAllCmd)
 	dashboardCmd.AddCommand(listCmd)
-	
+	dashboardCmd.AddCommand(pullCmd)
+	dashboardCmd.AddCommand(importFileCmd)
+	dashboardCmd.AddCommand(historyCmd)
+	dashboardCmd.AddCommand(rollbackCmd)
+	dashboardCmd.AddCommand(renderCmd)
+	dashboardCmd.AddCommand(lintCmd)
+	dashboardCmd.AddCommand(templatesCmd)
+
 	rootCmd.AddCommand(dashboardCmd)
 }
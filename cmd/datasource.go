@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"tunnel-monitor/internal/datasource"
+
+	"github.com/spf13/cobra"
+)
+
+var datasourceCmd = &cobra.Command{
+	Use:   "datasource",
+	Short: "管理 Grafana 数据源",
+	Long:  "创建和更新面板所需的 Prometheus 和 MySQL 数据源",
+}
+
+var datasourceSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "同步数据源",
+	Long:  "根据配置创建或更新 Prometheus 和 MySQL 数据源，UID 固定为 grafana.prometheus_uid 和 mysql.uid",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return datasource.Sync()
+	},
+}
+
+func init() {
+	datasourceCmd.AddCommand(datasourceSyncCmd)
+	rootCmd.AddCommand(datasourceCmd)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"tunnel-monitor/internal/config"
+	"tunnel-monitor/internal/version"
 )
 
 var cfgFile string
@@ -28,6 +29,8 @@ func Execute() error {
 func init() {
 	cobra.OnInitialize(initConfig)
 
+	rootCmd.Version = version.Version
+
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "配置文件路径 (默认: ./config.yaml)")
 }
 
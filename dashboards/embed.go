@@ -0,0 +1,11 @@
+// Package dashboards 内置的默认面板模板
+// 编译时将基础模板、面板清单、环境覆盖配置和panels片段打包进二进制文件，
+// 磁盘上不存在 dashboards.dir 时使用这里的模板
+package dashboards
+
+import "embed"
+
+// FS 内置模板，目录结构与仓库中的 dashboards 目录相同
+//
+//go:embed *.json manifests overlays panels
+var FS embed.FS
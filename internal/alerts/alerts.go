@@ -0,0 +1,117 @@
+package alerts
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"tunnel-monitor/internal/config"
+	"tunnel-monitor/internal/grafana"
+)
+
+// Sync 同步联系点、通知策略和告警规则
+// 规则组整体覆盖，仓库中已删除的规则会从 Grafana 中删除
+func Sync() error {
+	fmt.Println("🚨 同步告警规则...")
+
+	cfg := config.Global
+
+	rules, err := LoadRules(cfg.Alerts.Dir)
+	if err != nil {
+		return err
+	}
+
+	interval, err := time.ParseDuration(cfg.Alerts.Interval)
+	if err != nil {
+		return fmt.Errorf("alerts.interval 格式错误: %w", err)
+	}
+
+	client := grafana.NewClient()
+
+	if err := syncNotifications(client); err != nil {
+		return err
+	}
+
+	folderUID, err := client.EnsureFolder(cfg.Alerts.Folder)
+	if err != nil {
+		return err
+	}
+	if folderUID == "" {
+		return fmt.Errorf("告警规则必须放在文件夹中，请配置 alerts.folder")
+	}
+
+	groupPath := fmt.Sprintf("/api/v1/provisioning/folder/%s/rule-groups/%s", url.PathEscape(folderUID), url.PathEscape(cfg.Alerts.Group))
+
+	existing, err := existingRuleUIDs(client, groupPath)
+	if err != nil {
+		return fmt.Errorf("获取现有规则组失败: %w", err)
+	}
+
+	group := map[string]interface{}{
+		"title":     cfg.Alerts.Group,
+		"folderUid": folderUID,
+		"interval":  int(interval.Seconds()),
+	}
+
+	grafanaRules := make([]interface{}, 0, len(rules))
+	wanted := make(map[string]bool)
+	for _, rule := range rules {
+		grafanaRule, err := rule.toGrafana(folderUID, cfg.Alerts.Group)
+		if err != nil {
+			return fmt.Errorf("转换告警规则 %s 失败: %w", rule.UID, err)
+		}
+		grafanaRules = append(grafanaRules, grafanaRule)
+		wanted[rule.UID] = true
+	}
+	group["rules"] = grafanaRules
+
+	if err := client.Put(groupPath, group, nil); err != nil {
+		return fmt.Errorf("更新规则组 %s 失败: %w", cfg.Alerts.Group, err)
+	}
+
+	for _, rule := range rules {
+		if existing[rule.UID] {
+			fmt.Printf("✅ 已更新规则 %s (%s)\n", rule.Title, rule.UID)
+		} else {
+			fmt.Printf("✅ 已创建规则 %s (%s)\n", rule.Title, rule.UID)
+		}
+	}
+
+	// 覆盖规则组时 Grafana 会移除组内多余的规则，这里再显式删除一次，兼容旧版本
+	for uid := range existing {
+		if wanted[uid] {
+			continue
+		}
+		if err := client.Delete("/api/v1/provisioning/alert-rules/" + url.PathEscape(uid)); err != nil && !grafana.IsNotFound(err) {
+			return fmt.Errorf("删除规则 %s 失败: %w", uid, err)
+		}
+		fmt.Printf("🗑️ 已删除规则 %s\n", uid)
+	}
+
+	fmt.Printf("✅ 规则组 %s 同步完成，共 %d 条规则\n", cfg.Alerts.Group, len(rules))
+	return nil
+}
+
+// existingRuleUIDs 获取规则组中现有规则的UID，规则组不存在时返回空集合
+func existingRuleUIDs(client *grafana.Client, groupPath string) (map[string]bool, error) {
+	var group struct {
+		Rules []struct {
+			UID string `json:"uid"`
+		} `json:"rules"`
+	}
+
+	uids := make(map[string]bool)
+
+	if err := client.Get(groupPath, &group); err != nil {
+		if grafana.IsNotFound(err) {
+			return uids, nil
+		}
+		return nil, err
+	}
+
+	for _, rule := range group.Rules {
+		uids[rule.UID] = true
+	}
+
+	return uids, nil
+}
@@ -0,0 +1,221 @@
+package alerts
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"tunnel-monitor/internal/config"
+	"tunnel-monitor/internal/grafana"
+)
+
+// managedContactPointPrefix 本工具生成的联系点UID前缀，用于识别并清理已删除的联系点
+const managedContactPointPrefix = "tm-"
+
+// feishuPayloadTemplate 飞书机器人的消息格式
+// Grafana 没有内置飞书联系点，使用 webhook 联系点的自定义 payload（需要 Grafana 12 及以上）
+const feishuPayloadTemplate = `{{ coll.Dict "msg_type" "text" "content" (coll.Dict "text" (printf "[%s] %s\n%s" (.Status | toUpper) .CommonLabels.alertname .CommonAnnotations.summary)) | data.ToJSON }}`
+
+// syncNotifications 同步联系点和通知策略树
+// 未配置任何联系点时不做修改，避免覆盖 Grafana 中手动维护的通知策略
+func syncNotifications(client *grafana.Client) error {
+	cfg := config.Global.Notifications
+	if len(cfg.ContactPoints) == 0 {
+		fmt.Println("ℹ️ 未配置 notifications.contact_points，跳过通知配置")
+		return nil
+	}
+
+	contactPoints := make(map[string]map[string]interface{})
+	var names []string
+	for _, cp := range cfg.ContactPoints {
+		grafanaCP, err := contactPointToGrafana(cp)
+		if err != nil {
+			return err
+		}
+		if _, ok := contactPoints[cp.Name]; ok {
+			return fmt.Errorf("联系点 %s 重复", cp.Name)
+		}
+		contactPoints[cp.Name] = grafanaCP
+		names = append(names, cp.Name)
+	}
+
+	policy, err := buildPolicyTree(contactPoints)
+	if err != nil {
+		return err
+	}
+
+	var existing []struct {
+		UID  string `json:"uid"`
+		Name string `json:"name"`
+	}
+	if err := client.Get("/api/v1/provisioning/contact-points", &existing); err != nil {
+		return fmt.Errorf("获取联系点列表失败: %w", err)
+	}
+
+	existingUIDs := make(map[string]bool)
+	for _, cp := range existing {
+		existingUIDs[cp.UID] = true
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		cp := contactPoints[name]
+		uid := cp["uid"].(string)
+		wanted[uid] = true
+
+		if existingUIDs[uid] {
+			if err := client.Put("/api/v1/provisioning/contact-points/"+url.PathEscape(uid), cp, nil); err != nil {
+				return fmt.Errorf("更新联系点 %s 失败: %w", name, err)
+			}
+			fmt.Printf("✅ 已更新联系点 %s\n", name)
+		} else {
+			if err := client.Post("/api/v1/provisioning/contact-points", cp, nil); err != nil {
+				return fmt.Errorf("创建联系点 %s 失败: %w", name, err)
+			}
+			fmt.Printf("✅ 已创建联系点 %s\n", name)
+		}
+	}
+
+	if err := client.Put("/api/v1/provisioning/policies", policy, nil); err != nil {
+		return fmt.Errorf("更新通知策略失败: %w", err)
+	}
+	fmt.Printf("✅ 已更新通知策略（%d 条路由）\n", len(cfg.Routes))
+
+	// 通知策略不再引用后才能删除联系点
+	for _, cp := range existing {
+		if !strings.HasPrefix(cp.UID, managedContactPointPrefix) || wanted[cp.UID] {
+			continue
+		}
+		if err := client.Delete("/api/v1/provisioning/contact-points/" + url.PathEscape(cp.UID)); err != nil && !grafana.IsNotFound(err) {
+			return fmt.Errorf("删除联系点 %s 失败: %w", cp.Name, err)
+		}
+		fmt.Printf("🗑️ 已删除联系点 %s\n", cp.Name)
+	}
+
+	return nil
+}
+
+// contactPointToGrafana 将配置中的联系点转换为 Grafana 联系点
+func contactPointToGrafana(cp config.ContactPoint) (map[string]interface{}, error) {
+	if cp.Name == "" {
+		return nil, fmt.Errorf("联系点缺少 name")
+	}
+	if cp.URL == "" {
+		return nil, fmt.Errorf("联系点 %s 缺少 url", cp.Name)
+	}
+
+	var grafanaType string
+	var settings map[string]interface{}
+
+	switch cp.Type {
+	case "feishu":
+		grafanaType = "webhook"
+		settings = map[string]interface{}{
+			"url":        cp.URL,
+			"httpMethod": "POST",
+			"payload": map[string]interface{}{
+				"template": feishuPayloadTemplate,
+			},
+		}
+	case "dingtalk":
+		grafanaType = "dingding"
+		settings = map[string]interface{}{
+			"url":     cp.URL,
+			"msgType": "actionCard",
+		}
+	case "wecom":
+		grafanaType = "wecom"
+		settings = map[string]interface{}{
+			"url":     cp.URL,
+			"msgtype": "markdown",
+		}
+	default:
+		return nil, fmt.Errorf("联系点 %s 的类型 %q 不支持，可选 feishu、dingtalk、wecom", cp.Name, cp.Type)
+	}
+
+	uid := cp.UID
+	if uid == "" {
+		uid = contactPointUID(cp.Name)
+	}
+
+	return map[string]interface{}{
+		"uid":                   uid,
+		"name":                  cp.Name,
+		"type":                  grafanaType,
+		"settings":              settings,
+		"disableResolveMessage": false,
+	}, nil
+}
+
+// contactPointUID 根据联系点名称生成稳定的UID
+func contactPointUID(name string) string {
+	sum := sha1.Sum([]byte(name))
+	return managedContactPointPrefix + hex.EncodeToString(sum[:])[:16]
+}
+
+// buildPolicyTree 根据路由配置生成 Grafana 通知策略树
+func buildPolicyTree(contactPoints map[string]map[string]interface{}) (map[string]interface{}, error) {
+	cfg := config.Global.Notifications
+
+	defaultReceiver := cfg.DefaultContactPoint
+	if defaultReceiver == "" {
+		defaultReceiver = cfg.ContactPoints[0].Name
+	}
+	if _, ok := contactPoints[defaultReceiver]; !ok {
+		return nil, fmt.Errorf("默认联系点 %s 未在 contact_points 中定义", defaultReceiver)
+	}
+
+	routes := make([]interface{}, 0, len(cfg.Routes))
+	for i, route := range cfg.Routes {
+		if _, ok := contactPoints[route.ContactPoint]; !ok {
+			return nil, fmt.Errorf("第 %d 条路由的联系点 %s 未在 contact_points 中定义", i+1, route.ContactPoint)
+		}
+
+		matchers := routeMatchers(route)
+		if len(matchers) == 0 {
+			return nil, fmt.Errorf("第 %d 条路由（%s）没有配置 match 或 match_re", i+1, route.ContactPoint)
+		}
+
+		grafanaRoute := map[string]interface{}{
+			"receiver":        route.ContactPoint,
+			"object_matchers": matchers,
+			"continue":        route.Continue,
+		}
+		if len(route.GroupBy) > 0 {
+			grafanaRoute["group_by"] = route.GroupBy
+		}
+		routes = append(routes, grafanaRoute)
+	}
+
+	return map[string]interface{}{
+		"receiver": defaultReceiver,
+		"group_by": cfg.GroupBy,
+		"routes":   routes,
+	}, nil
+}
+
+// routeMatchers 生成 Grafana 的 object_matchers，按标签名排序保证结果稳定
+func routeMatchers(route config.NotificationRoute) [][]string {
+	var matchers [][]string
+
+	for _, label := range sortedKeys(route.Match) {
+		matchers = append(matchers, []string{label, "=", route.Match[label]})
+	}
+	for _, label := range sortedKeys(route.MatchRE) {
+		matchers = append(matchers, []string{label, "=~", route.MatchRE[label]})
+	}
+
+	return matchers
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,258 @@
+package alerts
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tunnel-monitor/internal/config"
+	"tunnel-monitor/internal/grafana"
+)
+
+// grafanaRequest 测试 Grafana 收到的一次请求
+type grafanaRequest struct {
+	Method string
+	Path   string
+	Body   interface{}
+}
+
+// newTestGrafana 启动记录请求的 Grafana，联系点列表返回 existing
+func newTestGrafana(t *testing.T, existing string) (*[]grafanaRequest, func()) {
+	t.Helper()
+
+	var requests []grafanaRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("%s %s 的 Authorization = %q", r.Method, r.URL.Path, got)
+		}
+
+		req := grafanaRequest{Method: r.Method, Path: r.URL.Path}
+		data, _ := io.ReadAll(r.Body)
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &req.Body); err != nil {
+				t.Errorf("%s %s 的请求体不是 JSON: %v", r.Method, r.URL.Path, err)
+			}
+		}
+		requests = append(requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/provisioning/contact-points" {
+			io.WriteString(w, existing)
+			return
+		}
+		io.WriteString(w, "{}")
+	}))
+
+	config.Global = &config.Config{}
+	config.Global.Grafana.URL = srv.URL
+	config.Global.Grafana.APIKey = "test-key"
+
+	return &requests, srv.Close
+}
+
+// mustJSON 将期望的 JSON 解析为与请求体可比较的值
+func mustJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("期望的 JSON 格式错误: %v", err)
+	}
+	return v
+}
+
+func TestSyncNotifications(t *testing.T) {
+	requests, closeServer := newTestGrafana(t, `[
+		{"uid": "tm-dingtalk", "name": "钉钉"},
+		{"uid": "tm-0123456789abcdef", "name": "已删除的联系点"},
+		{"uid": "manual", "name": "手动维护"}
+	]`)
+	defer closeServer()
+
+	cfg := &config.Global.Notifications
+	cfg.GroupBy = []string{"grafana_folder", "alertname"}
+	cfg.ContactPoints = []config.ContactPoint{
+		{Name: "飞书", Type: "feishu", URL: "https://open.feishu.cn/open-apis/bot/v2/hook/x"},
+		{Name: "钉钉", Type: "dingtalk", URL: "https://oapi.dingtalk.com/robot/send?access_token=x", UID: "tm-dingtalk"},
+		{Name: "企业微信", Type: "wecom", URL: "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=x"},
+	}
+	cfg.Routes = []config.NotificationRoute{
+		{ContactPoint: "钉钉", Match: map[string]string{"severity": "critical", "app": "tunnel"}, Continue: true},
+		{ContactPoint: "企业微信", MatchRE: map[string]string{"instance": "pop-.*"}, GroupBy: []string{"instance"}},
+	}
+
+	if err := syncNotifications(grafana.NewClient()); err != nil {
+		t.Fatalf("syncNotifications: %v", err)
+	}
+
+	feishuUID := contactPointUID("飞书")
+	wecomUID := contactPointUID("企业微信")
+	want := []grafanaRequest{
+		{Method: "GET", Path: "/api/v1/provisioning/contact-points"},
+		{Method: "POST", Path: "/api/v1/provisioning/contact-points", Body: mustJSON(t, `{
+			"uid": "`+feishuUID+`",
+			"name": "飞书",
+			"type": "webhook",
+			"disableResolveMessage": false,
+			"settings": {
+				"url": "https://open.feishu.cn/open-apis/bot/v2/hook/x",
+				"httpMethod": "POST",
+				"payload": {"template": `+jsonString(feishuPayloadTemplate)+`}
+			}
+		}`)},
+		{Method: "PUT", Path: "/api/v1/provisioning/contact-points/tm-dingtalk", Body: mustJSON(t, `{
+			"uid": "tm-dingtalk",
+			"name": "钉钉",
+			"type": "dingding",
+			"disableResolveMessage": false,
+			"settings": {
+				"url": "https://oapi.dingtalk.com/robot/send?access_token=x",
+				"msgType": "actionCard"
+			}
+		}`)},
+		{Method: "POST", Path: "/api/v1/provisioning/contact-points", Body: mustJSON(t, `{
+			"uid": "`+wecomUID+`",
+			"name": "企业微信",
+			"type": "wecom",
+			"disableResolveMessage": false,
+			"settings": {
+				"url": "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=x",
+				"msgtype": "markdown"
+			}
+		}`)},
+		{Method: "PUT", Path: "/api/v1/provisioning/policies", Body: mustJSON(t, `{
+			"receiver": "飞书",
+			"group_by": ["grafana_folder", "alertname"],
+			"routes": [
+				{
+					"receiver": "钉钉",
+					"object_matchers": [["app", "=", "tunnel"], ["severity", "=", "critical"]],
+					"continue": true
+				},
+				{
+					"receiver": "企业微信",
+					"object_matchers": [["instance", "=~", "pop-.*"]],
+					"continue": false,
+					"group_by": ["instance"]
+				}
+			]
+		}`)},
+		{Method: "DELETE", Path: "/api/v1/provisioning/contact-points/tm-0123456789abcdef"},
+	}
+
+	if len(*requests) != len(want) {
+		t.Fatalf("收到 %d 个请求，期望 %d 个: %+v", len(*requests), len(want), *requests)
+	}
+	for i, got := range *requests {
+		if got.Method != want[i].Method || got.Path != want[i].Path {
+			t.Errorf("第 %d 个请求 = %s %s，期望 %s %s", i+1, got.Method, got.Path, want[i].Method, want[i].Path)
+			continue
+		}
+		if !reflect.DeepEqual(got.Body, want[i].Body) {
+			gotJSON, _ := json.MarshalIndent(got.Body, "", "  ")
+			wantJSON, _ := json.MarshalIndent(want[i].Body, "", "  ")
+			t.Errorf("%s %s 的请求体:\n%s\n期望:\n%s", got.Method, got.Path, gotJSON, wantJSON)
+		}
+	}
+}
+
+func TestSyncNotificationsWithoutContactPoints(t *testing.T) {
+	requests, closeServer := newTestGrafana(t, `[]`)
+	defer closeServer()
+
+	if err := syncNotifications(grafana.NewClient()); err != nil {
+		t.Fatalf("syncNotifications: %v", err)
+	}
+	if len(*requests) != 0 {
+		t.Errorf("未配置联系点时不应请求 Grafana，收到 %+v", *requests)
+	}
+}
+
+func TestSyncNotificationsInvalidConfig(t *testing.T) {
+	feishu := config.ContactPoint{Name: "飞书", Type: "feishu", URL: "http://x"}
+
+	tests := []struct {
+		name          string
+		contactPoints []config.ContactPoint
+		defaultCP     string
+		routes        []config.NotificationRoute
+		wantErr       string
+	}{
+		{
+			name:          "不支持的类型",
+			contactPoints: []config.ContactPoint{{Name: "slack", Type: "slack", URL: "http://x"}},
+			wantErr:       "不支持",
+		},
+		{
+			name:          "缺少 url",
+			contactPoints: []config.ContactPoint{{Name: "飞书", Type: "feishu"}},
+			wantErr:       "缺少 url",
+		},
+		{
+			name:          "联系点重复",
+			contactPoints: []config.ContactPoint{feishu, feishu},
+			wantErr:       "重复",
+		},
+		{
+			name:          "默认联系点未定义",
+			contactPoints: []config.ContactPoint{feishu},
+			defaultCP:     "钉钉",
+			wantErr:       "默认联系点 钉钉",
+		},
+		{
+			name:          "路由的联系点未定义",
+			contactPoints: []config.ContactPoint{feishu},
+			routes:        []config.NotificationRoute{{ContactPoint: "钉钉", Match: map[string]string{"a": "b"}}},
+			wantErr:       "第 1 条路由的联系点 钉钉",
+		},
+		{
+			name:          "路由没有匹配条件",
+			contactPoints: []config.ContactPoint{feishu},
+			routes:        []config.NotificationRoute{{ContactPoint: "飞书"}},
+			wantErr:       "没有配置 match",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, closeServer := newTestGrafana(t, `[]`)
+			defer closeServer()
+
+			cfg := &config.Global.Notifications
+			cfg.ContactPoints = tt.contactPoints
+			cfg.DefaultContactPoint = tt.defaultCP
+			cfg.Routes = tt.routes
+
+			err := syncNotifications(grafana.NewClient())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+			}
+			if len(*requests) != 0 {
+				t.Errorf("配置错误时不应请求 Grafana，收到 %+v", *requests)
+			}
+		})
+	}
+}
+
+func TestContactPointUIDStable(t *testing.T) {
+	uid := contactPointUID("飞书")
+	if uid != contactPointUID("飞书") {
+		t.Fatal("同一名称生成的UID不同")
+	}
+	if !strings.HasPrefix(uid, managedContactPointPrefix) || len(uid) != len(managedContactPointPrefix)+16 {
+		t.Errorf("UID %q 格式错误", uid)
+	}
+	if uid == contactPointUID("钉钉") {
+		t.Error("不同名称生成了相同的UID")
+	}
+}
+
+// jsonString 将字符串编码为 JSON 字符串字面量
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -0,0 +1,218 @@
+package alerts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"tunnel-monitor/internal/config"
+)
+
+// RuleFile 告警规则文件，一个文件可以包含多条规则
+type RuleFile struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule 仓库中维护的告警规则定义
+type Rule struct {
+	UID          string            `yaml:"uid"`
+	Title        string            `yaml:"title"`
+	Datasource   string            `yaml:"datasource"` // 数据源占位符，如 {{PROMETHEUS_UID}}
+	Expr         string            `yaml:"expr"`
+	Condition    string            `yaml:"condition"` // gt（大于阈值触发）或 lt（小于阈值触发）
+	Threshold    float64           `yaml:"threshold"`
+	For          string            `yaml:"for"` // 条件持续多久后触发
+	Labels       map[string]string `yaml:"labels"`
+	Annotations  map[string]string `yaml:"annotations"`
+	NoDataState  string            `yaml:"no_data_state"`  // NoData、Alerting、OK，默认 NoData
+	ExecErrState string            `yaml:"exec_err_state"` // Error、Alerting、OK，默认 Error
+
+	file string // 规则所在文件，用于错误提示
+}
+
+// LoadRules 加载目录中的所有告警规则（*.yaml、*.yml），按文件名排序
+func LoadRules(dir string) ([]Rule, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取告警规则目录失败: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rules []Rule
+	seen := make(map[string]string)
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取告警规则文件 %s 失败: %w", path, err)
+		}
+
+		var file RuleFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("解析告警规则文件 %s 失败: %w", path, err)
+		}
+
+		for _, rule := range file.Rules {
+			rule.file = path
+			if err := rule.validate(); err != nil {
+				return nil, err
+			}
+			if other, ok := seen[rule.UID]; ok {
+				return nil, fmt.Errorf("告警规则UID %s 重复（%s 和 %s）", rule.UID, other, path)
+			}
+			seen[rule.UID] = path
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// validate 检查规则定义是否完整
+func (r *Rule) validate() error {
+	prefix := fmt.Sprintf("告警规则 %s (%s)", r.UID, r.file)
+
+	switch {
+	case r.UID == "":
+		return fmt.Errorf("%s 文件中存在未设置 uid 的告警规则", r.file)
+	case len(r.UID) > 40:
+		return fmt.Errorf("%s: uid 不能超过40个字符", prefix)
+	case r.Title == "":
+		return fmt.Errorf("%s: 缺少 title", prefix)
+	case r.Expr == "":
+		return fmt.Errorf("%s: 缺少 expr", prefix)
+	case r.Condition != "gt" && r.Condition != "lt":
+		return fmt.Errorf("%s: condition 只能是 gt 或 lt，当前为 %q", prefix, r.Condition)
+	}
+
+	if _, err := r.datasourceUID(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	if r.For != "" {
+		if _, err := time.ParseDuration(r.For); err != nil {
+			return fmt.Errorf("%s: for 格式错误: %w", prefix, err)
+		}
+	}
+
+	return nil
+}
+
+// datasourceUID 解析规则的数据源占位符，未设置时使用 Prometheus 数据源
+func (r *Rule) datasourceUID() (string, error) {
+	ref := r.Datasource
+	if ref == "" {
+		ref = "{{PROMETHEUS_UID}}"
+	}
+
+	uid, ok := config.Global.DatasourcePlaceholders()[ref]
+	if !ok {
+		return "", fmt.Errorf("未知的数据源占位符 %s", ref)
+	}
+	if uid == "" {
+		return "", fmt.Errorf("数据源占位符 %s 未配置UID", ref)
+	}
+
+	return uid, nil
+}
+
+// toGrafana 将规则转换为 Grafana 告警配置 API 的规则格式
+// 查询链：A 即时查询 -> B 取最新值 -> C 与阈值比较
+func (r *Rule) toGrafana(folderUID, group string) (map[string]interface{}, error) {
+	datasourceUID, err := r.datasourceUID()
+	if err != nil {
+		return nil, err
+	}
+
+	forDuration := r.For
+	if forDuration == "" {
+		forDuration = "0s"
+	}
+
+	noDataState := r.NoDataState
+	if noDataState == "" {
+		noDataState = "NoData"
+	}
+
+	execErrState := r.ExecErrState
+	if execErrState == "" {
+		execErrState = "Error"
+	}
+
+	labels := r.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	annotations := r.Annotations
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	return map[string]interface{}{
+		"uid":       r.UID,
+		"title":     r.Title,
+		"folderUID": folderUID,
+		"ruleGroup": group,
+		"condition": "C",
+		"data": []interface{}{
+			map[string]interface{}{
+				"refId":             "A",
+				"datasourceUid":     datasourceUID,
+				"relativeTimeRange": map[string]int{"from": 600, "to": 0},
+				"model": map[string]interface{}{
+					"refId":   "A",
+					"expr":    r.Expr,
+					"instant": true,
+				},
+			},
+			map[string]interface{}{
+				"refId":         "B",
+				"datasourceUid": "__expr__",
+				"model": map[string]interface{}{
+					"refId":      "B",
+					"type":       "reduce",
+					"expression": "A",
+					"reducer":    "last",
+				},
+			},
+			map[string]interface{}{
+				"refId":         "C",
+				"datasourceUid": "__expr__",
+				"model": map[string]interface{}{
+					"refId":      "C",
+					"type":       "threshold",
+					"expression": "B",
+					"conditions": []interface{}{
+						map[string]interface{}{
+							"evaluator": map[string]interface{}{
+								"type":   r.Condition,
+								"params": []float64{r.Threshold},
+							},
+						},
+					},
+				},
+			},
+		},
+		"for":          forDuration,
+		"noDataState":  noDataState,
+		"execErrState": execErrState,
+		"labels":       labels,
+		"annotations":  annotations,
+	}, nil
+}
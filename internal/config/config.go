@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,12 +19,13 @@ type Config struct {
 	} `yaml:"prometheus"`
 
 	Grafana struct {
-		URL           string `yaml:"url"`
-		Port          int    `yaml:"port"`
-		Username      string `yaml:"username"`
-		Password      string `yaml:"password"`
-		APIKey        string `yaml:"api_key"`
-		PrometheusUID string `yaml:"prometheus_uid"` // Grafana中Prometheus数据源UID
+		URL                 string `yaml:"url"`
+		Port                int    `yaml:"port"`
+		Username            string `yaml:"username"`
+		Password            string `yaml:"password"`
+		APIKey              string `yaml:"api_key"`
+		ServiceAccountToken string `yaml:"service_account_token"` // 服务账号令牌，优先于 APIKey 和用户名密码
+		PrometheusUID       string `yaml:"prometheus_uid"`        // Grafana中Prometheus数据源UID
 	} `yaml:"grafana"`
 
 	Server struct {
@@ -39,18 +41,64 @@ type Config struct {
 		Username string `yaml:"username"`
 		Password string `yaml:"password"`
 		UID      string `yaml:"uid"` // Grafana数据源UID
+
+		ExporterAddress string `yaml:"exporter_address"` // mysqld_exporter 地址，数据库监控面板的 QPS 和慢查询来自该指标
 	} `yaml:"mysql"`
 
 	Dashboards struct {
-		ServerTemplate   string `yaml:"server_template"`
-		ClientTemplate   string `yaml:"client_template"`
-		DatabaseTemplate string `yaml:"database_template"`
-		BusinessTemplate string `yaml:"business_template"`
-		UnifiedUID       string `yaml:"unified_uid"`
-		ServerUID        string `yaml:"server_uid"`
-		DatabaseUID      string `yaml:"database_uid"`
-		BusinessUID      string `yaml:"business_uid"`
+		Dir string `yaml:"dir"` // 模板根目录，面板清单位于 <dir>/manifests
+
+		// 覆盖面板清单中的 uid，未设置时使用清单中的 uid
+		UnifiedUID  string `yaml:"unified_uid"` // 客户端监控面板UID
+		ServerUID   string `yaml:"server_uid"`
+		DatabaseUID string `yaml:"database_uid"`
+		BusinessUID string `yaml:"business_uid"`
+
+		// Grafana文件夹，Folders 按面板名称（清单名称）覆盖默认的 Folder
+		Folder  string            `yaml:"folder"`
+		Folders map[string]string `yaml:"folders"`
+
+		LibraryPanels bool `yaml:"library_panels"` // 将panels片段发布为 Grafana 库面板
 	} `yaml:"dashboards"`
+
+	// 告警规则配置
+	Alerts struct {
+		Dir      string `yaml:"dir"`      // 告警规则 YAML 目录
+		Folder   string `yaml:"folder"`   // 告警规则所在的 Grafana 文件夹
+		Group    string `yaml:"group"`    // 由本工具管理的规则组
+		Interval string `yaml:"interval"` // 规则组评估间隔
+	} `yaml:"alerts"`
+
+	// 告警通知配置
+	Notifications struct {
+		ContactPoints       []ContactPoint      `yaml:"contact_points"`
+		DefaultContactPoint string              `yaml:"default_contact_point"` // 未匹配任何路由时使用的联系点，默认第一个
+		GroupBy             []string            `yaml:"group_by"`
+		Routes              []NotificationRoute `yaml:"routes"`
+	} `yaml:"notifications"`
+
+	// 模板变量，模板中的 {{NAME}} 占位符替换为同名变量的值，名称使用大写字母、数字和下划线
+	TemplateVars map[string]interface{} `yaml:"template_vars"`
+
+	// 部署具备的功能，模板中通过 {% if .Features.DNS %} 按功能包含或排除内容
+	Features map[string]bool `yaml:"features"`
+}
+
+// ContactPoint 告警联系点（IM 机器人 webhook）
+type ContactPoint struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"` // feishu、dingtalk、wecom
+	URL  string `yaml:"url"`
+	UID  string `yaml:"uid"` // 可选，默认根据名称生成
+}
+
+// NotificationRoute 按标签将告警路由到联系点
+type NotificationRoute struct {
+	ContactPoint string            `yaml:"contact_point"`
+	Match        map[string]string `yaml:"match"`    // 标签等值匹配
+	MatchRE      map[string]string `yaml:"match_re"` // 标签正则匹配
+	Continue     bool              `yaml:"continue"` // 匹配后是否继续匹配后续路由
+	GroupBy      []string          `yaml:"group_by"`
 }
 
 var configFile = "./config.yaml"
@@ -75,11 +123,40 @@ func Load() error {
 		if err := yaml.Unmarshal(data, Global); err != nil {
 			return fmt.Errorf("解析配置文件失败: %w", err)
 		}
+		if err := checkLegacyKeys(data); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// legacyDashboardKeys 已移除的 dashboards 配置项，面板改为由 dashboards.dir 中的面板清单组合
+var legacyDashboardKeys = []string{"business_template", "server_template", "client_template", "database_template"}
+
+// checkLegacyKeys 配置文件中仍有已移除的模板配置时返回错误
+// yaml 会忽略未知字段，不检查时指向自定义模板的配置会被静默替换为内置模板
+func checkLegacyKeys(data []byte) error {
+	var raw struct {
+		Dashboards map[string]interface{} `yaml:"dashboards"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("解析配置文件失败: %w", err)
+	}
+
+	var found []string
+	for _, key := range legacyDashboardKeys {
+		if _, ok := raw.Dashboards[key]; ok {
+			found = append(found, "dashboards."+key)
+		}
+	}
+	if len(found) > 0 {
+		return fmt.Errorf("配置项 %s 已不再支持：面板由 dashboards.dir 下的面板清单（manifests/*.yaml）组合，"+
+			"请将自定义模板放入 dashboards.dir 并修改对应清单的 base，然后删除这些配置项", strings.Join(found, "、"))
+	}
+	return nil
+}
+
 func setDefaults() {
 	Global.Prometheus.URL = "http://localhost:9090"
 	Global.Prometheus.Port = 9090
@@ -99,16 +176,53 @@ func setDefaults() {
 	Global.MySQL.Username = "root"
 	Global.MySQL.Password = ""
 	Global.MySQL.UID = "mysql-datasource" // 默认MySQL数据源UID
+	Global.MySQL.ExporterAddress = "127.0.0.1:9104"
 
 	Global.Server.MetricsURL = "http://localhost:8001/metrics"
 	Global.Server.Port = 8001
 
-	Global.Dashboards.ServerTemplate = "./dashboards/server-template.json"
-	Global.Dashboards.ClientTemplate = "./dashboards/client-template.json"
-	Global.Dashboards.DatabaseTemplate = "./dashboards/database-template.json"
-	Global.Dashboards.UnifiedUID = "pop-clients-unified"
-	Global.Dashboards.ServerUID = "tunnel-server"
-	Global.Dashboards.DatabaseUID = "tunnel-database"
+	Global.Dashboards.Dir = "./dashboards"
+	Global.Dashboards.Folder = "IPTunnel"
+
+	Global.Alerts.Dir = "./alerts"
+	Global.Alerts.Folder = "IPTunnel"
+	Global.Alerts.Group = "tunnel-monitor"
+	Global.Alerts.Interval = "1m"
+
+	Global.Notifications.GroupBy = []string{"grafana_folder", "alertname"}
+
+	// 默认部署包含所有功能，配置文件中可以按功能关闭
+	Global.Features = map[string]bool{
+		"MySQL": true,
+		"DNS":   true,
+	}
+
+	// 模板中使用的变量，配置文件中的同名变量会覆盖这里的默认值
+	Global.TemplateVars = map[string]interface{}{
+		"REFRESH":             "10s",
+		"LATENCY_CRITICAL_MS": 80,
+	}
+}
+
+// DatasourcePlaceholders 返回模板中的数据源占位符及其对应的数据源UID
+func (c *Config) DatasourcePlaceholders() map[string]string {
+	return map[string]string{
+		"{{PROMETHEUS_UID}}": c.Grafana.PrometheusUID,
+		"{{MYSQL_UID}}":      c.MySQL.UID,
+	}
+}
+
+// TemplatePlaceholders 返回模板中所有可用的占位符及其值
+// 包括 template_vars 中的变量和数据源占位符，数据源占位符不能被覆盖
+func (c *Config) TemplatePlaceholders() map[string]interface{} {
+	placeholders := make(map[string]interface{})
+	for name, value := range c.TemplateVars {
+		placeholders["{{"+name+"}}"] = value
+	}
+	for placeholder, uid := range c.DatasourcePlaceholders() {
+		placeholders[placeholder] = uid
+	}
+	return placeholders
 }
 
 func Save() error {
@@ -0,0 +1,97 @@
+package dashboard
+
+import "sort"
+
+// 片段中控制排列的扩展字段，导入 Grafana 前会被移除
+const (
+	orderField = "x-order" // 同一 row 内的排列顺序，数值小的在前
+	rowField   = "x-row"   // 所属 row 的标题
+)
+
+// panelGroup 一个 row 及其包含的 panel，row 为 nil 表示位于所有 row 之前的 panel
+type panelGroup struct {
+	row    *Panel
+	panels []*Panel
+}
+
+// newRowPanel 创建 row 面板，gridPos 和 ID 在组合完成后统一计算
+func newRowPanel(title string, id int, collapsed bool) *Panel {
+	return &Panel{
+		ID:        id,
+		Type:      "row",
+		Title:     title,
+		Panels:    []*Panel{},
+		Collapsed: collapsed,
+		present:   map[string]bool{"collapsed": true},
+	}
+}
+
+// arrangePanels 按片段中的 x-row 和 x-order 排列 panel，返回展开后的 panels 列表
+// 设置了 x-row 的 panel 移到同名 row 中，没有同名 row 时在最后新建一个展开的 row；
+// 每个 row 内按 x-order 从小到大稳定排序，未设置 x-order 的 panel 保持原有顺序排在后面
+func arrangePanels(groups []*panelGroup) []*Panel {
+	byTitle := make(map[string]*panelGroup)
+	for _, g := range groups {
+		if g.row != nil {
+			byTitle[g.row.Title] = g
+		}
+	}
+
+	for _, g := range groups {
+		var kept []*Panel
+		for _, p := range g.panels {
+			title := p.extraString(rowField)
+			if title == "" || (g.row != nil && g.row.Title == title) {
+				kept = append(kept, p)
+				continue
+			}
+
+			target, ok := byTitle[title]
+			if !ok {
+				target = &panelGroup{row: newRowPanel(title, 0, false)}
+				byTitle[title] = target
+				groups = append(groups, target)
+			}
+			target.panels = append(target.panels, p)
+		}
+		g.panels = kept
+	}
+
+	var result []*Panel
+	for _, g := range groups {
+		sortByOrder(g.panels)
+
+		if g.row == nil {
+			result = append(result, g.panels...)
+			continue
+		}
+
+		if g.row.Collapsed {
+			g.row.Panels = append([]*Panel{}, g.panels...)
+			result = append(result, g.row)
+		} else {
+			result = append(result, g.row)
+			result = append(result, g.panels...)
+		}
+	}
+
+	return result
+}
+
+// sortByOrder 按 x-order 稳定排序，未设置的排在最后
+func sortByOrder(panels []*Panel) {
+	sort.SliceStable(panels, func(i, j int) bool {
+		oi, iok := panelOrder(panels[i])
+		oj, jok := panelOrder(panels[j])
+		if iok != jok {
+			return iok
+		}
+		return oi < oj
+	})
+}
+
+// panelOrder 返回 panel 的 x-order
+func panelOrder(p *Panel) (float64, bool) {
+	order, ok := p.Extra[orderField].(float64)
+	return order, ok
+}
@@ -7,19 +7,13 @@ import (
 )
 
 // AddInstanceVariable 为 dashboard 添加 instance 变量
-func AddInstanceVariable(dashboard map[string]interface{}, instances []string) error {
-	templating := make(map[string]interface{})
-	if val, ok := dashboard["templating"]; ok {
-		templating = val.(map[string]interface{})
-	}
-
-	list := []interface{}{}
-	if val, ok := templating["list"]; ok {
-		list = val.([]interface{})
+func AddInstanceVariable(dashboard *Dashboard, instances []string) error {
+	if dashboard.Templating == nil {
+		dashboard.Templating = &Templating{}
 	}
 
 	// 创建选项
-	options := []map[string]interface{}{}
+	options := []interface{}{}
 	for i, inst := range instances {
 		options = append(options, map[string]interface{}{
 			"text":     inst,
@@ -30,77 +24,52 @@ func AddInstanceVariable(dashboard map[string]interface{}, instances []string) e
 
 	// 根据面板类型确定标签
 	label := "客户端实例"
-	if title, ok := dashboard["title"].(string); ok {
-		if strings.Contains(title, "服务端") || strings.Contains(title, "Server") {
-			label = "服务端实例"
-		}
+	if strings.Contains(dashboard.Title, "服务端") || strings.Contains(dashboard.Title, "Server") {
+		label = "服务端实例"
 	}
 
-	instanceVar := map[string]interface{}{
-		"name":  "instance",
-		"type":  "custom",
-		"label": label,
-		"options":     options,
-		"query":       strings.Join(instances, ","),
-		"hide":        0,
-		"includeAll":  false,
-		"multi":       false,
-		"refresh":     1,
-		"regex":       "",
-		"skipUrlSync": false,
-		"sort":        0,
+	instanceVar := &TemplateVar{
+		Name:  "instance",
+		Type:  "custom",
+		Label: label,
+		Query: strings.Join(instances, ","),
+		Extra: map[string]interface{}{
+			"options":     options,
+			"hide":        0,
+			"includeAll":  false,
+			"multi":       false,
+			"refresh":     1,
+			"regex":       "",
+			"skipUrlSync": false,
+			"sort":        0,
+		},
 	}
 
 	// 移除已存在的 instance 变量
-	newList := []interface{}{}
-	for _, v := range list {
-		if varMap, ok := v.(map[string]interface{}); ok {
-			if varMap["name"] != "instance" {
-				newList = append(newList, v)
-			}
+	newList := []*TemplateVar{}
+	for _, v := range dashboard.Templating.List {
+		if v.Name != "instance" {
+			newList = append(newList, v)
 		}
 	}
 
 	// 添加到开头
-	newList = append([]interface{}{instanceVar}, newList...)
-
-	templating["list"] = newList
-	dashboard["templating"] = templating
+	dashboard.Templating.List = append([]*TemplateVar{instanceVar}, newList...)
 
 	return nil
 }
 
 // AddInstanceFilterToQueries 为所有查询添加 instance 过滤
-func AddInstanceFilterToQueries(dashboard map[string]interface{}) error {
-	panels, ok := dashboard["panels"].([]interface{})
-	if !ok {
-		return nil
-	}
-
-	for _, p := range panels {
-		panel, ok := p.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
+func AddInstanceFilterToQueries(dashboard *Dashboard) error {
+	for _, panel := range dashboard.Panels {
 		// 跳过 text 面板
-		if panel["type"] == "text" {
-			continue
-		}
-
-		targets, ok := panel["targets"].([]interface{})
-		if !ok {
+		if panel.Type == "text" {
 			continue
 		}
 
-		for _, t := range targets {
-			target, ok := t.(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			expr, ok := target["expr"].(string)
-			if !ok {
+		for _, target := range panel.Targets {
+			expr := target.Expr
+			if expr == "" {
 				continue
 			}
 
@@ -115,41 +84,74 @@ func AddInstanceFilterToQueries(dashboard map[string]interface{}) error {
 			// 添加 instance 变量
 			expr = addInstanceVariableToQuery(expr)
 
-			target["expr"] = expr
+			target.Expr = expr
 		}
 	}
 
 	return nil
 }
 
-// FixDatasource 修复 dashboard 中的数据源引用
-func FixDatasource(dashboard map[string]interface{}) {
-	fixDatasourceRecursive(dashboard)
+// RestoreDatasourcePlaceholders 将 dashboard 中的具体数据源UID还原为模板占位符
+// 与 ResolvePlaceholders 相反，用于把 Grafana 中的 dashboard 导出为模板
+// 先按配置中的UID匹配，匹配不到时按数据源类型还原
+// 数据源引用不在模型的已建模字段中，只需处理各层的 Extra
+func RestoreDatasourcePlaceholders(dashboard *Dashboard) {
+	for _, extra := range dashboard.extras() {
+		restoreDatasourceRecursive(extra)
+	}
 }
 
-func fixDatasourceRecursive(obj interface{}) {
+func restoreDatasourceRecursive(obj interface{}) {
 	switch v := obj.(type) {
 	case map[string]interface{}:
 		if ds, ok := v["datasource"].(map[string]interface{}); ok {
-			if uid, ok := ds["uid"].(string); ok {
-				switch uid {
-				case "{{PROMETHEUS_UID}}":
-					// 替换Prometheus数据源UID占位符
-					ds["uid"] = config.Global.Grafana.PrometheusUID
-				case "{{MYSQL_UID}}":
-					// 替换MySQL数据源UID占位符
-					ds["uid"] = config.Global.MySQL.UID
-				}
+			if placeholder := datasourcePlaceholder(ds); placeholder != "" {
+				ds["uid"] = placeholder
 			}
 		}
 		for _, val := range v {
-			fixDatasourceRecursive(val)
+			restoreDatasourceRecursive(val)
 		}
 	case []interface{}:
 		for _, item := range v {
-			fixDatasourceRecursive(item)
+			restoreDatasourceRecursive(item)
+		}
+	}
+}
+
+// datasourcePlaceholder 返回数据源引用对应的占位符，无法识别时返回空字符串
+func datasourcePlaceholder(ds map[string]interface{}) string {
+	uid := getString(ds, "uid")
+	if uid == "" || strings.HasPrefix(uid, "{{") || strings.HasPrefix(uid, "$") {
+		// 空UID、已是占位符或引用了变量的数据源保持不变
+		return ""
+	}
+
+	// 先按数据源类型匹配，prometheus_uid 与 mysql.uid 相同时结果由类型决定；没有类型时按固定顺序匹配
+	placeholders := config.Global.DatasourcePlaceholders()
+	dsType := getString(ds, "type")
+	if placeholder := typePlaceholder(dsType); placeholder != "" && placeholders[placeholder] == uid {
+		return placeholder
+	}
+	for _, placeholder := range []string{"{{PROMETHEUS_UID}}", "{{MYSQL_UID}}"} {
+		if placeholders[placeholder] == uid {
+			return placeholder
 		}
 	}
+
+	return typePlaceholder(dsType)
+}
+
+// typePlaceholder 返回数据源类型对应的UID占位符，不支持的类型返回空字符串
+func typePlaceholder(dsType string) string {
+	switch dsType {
+	case "prometheus":
+		return "{{PROMETHEUS_UID}}"
+	case "mysql":
+		return "{{MYSQL_UID}}"
+	}
+
+	return ""
 }
 
 func removeInstanceFilter(expr string) string {
@@ -323,41 +325,15 @@ func findMetricNameEnd(expr string, start int) int {
 // 实现方式：修改查询表达式，使用or操作符实现条件显示
 // 当username="All"（即.*）时，总体查询显示，用户查询返回空
 // 当选择特定用户时，总体查询返回空，用户查询正常显示
-func AddUsernameControlToPacketRatePanel(dashboard map[string]interface{}) error {
-	panels, ok := dashboard["panels"].([]interface{})
-	if !ok {
-		return nil
-	}
-
-	for _, p := range panels {
-		panel, ok := p.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
+func AddUsernameControlToPacketRatePanel(dashboard *Dashboard) error {
+	for _, panel := range dashboard.Panels {
 		// 只处理Packet Rate面板
-		title, ok := panel["title"].(string)
-		if !ok || title != "Packet Rate" {
-			continue
-		}
-
-		targets, ok := panel["targets"].([]interface{})
-		if !ok {
+		if panel.Title != "Packet Rate" {
 			continue
 		}
 
 		// 修改查询表达式，使其根据username变量动态显示
-		for _, t := range targets {
-			target, ok := t.(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			refId, ok := target["refId"].(string)
-			if !ok {
-				continue
-			}
-
+		for _, target := range panel.Targets {
 			// refId A和B是总体统计（RX/TX Packets Total）
 			// 当username="All"（即.*）时显示，选择特定用户时隐藏
 			// 实现方式：使用count检查用户查询匹配的用户数
@@ -365,17 +341,17 @@ func AddUsernameControlToPacketRatePanel(dashboard map[string]interface{}) error
 			// 当选择特定用户时，count=1（或小于总用户数），隐藏总体
 			// 技巧：当count等于总用户数时（即选择了All），显示总体；否则返回0
 
-			switch refId {
+			switch target.RefID {
 			case "A":
 				// RX Packets Total：当用户查询匹配的用户数等于总用户数时显示，否则返回0
 				// 使用count检查：count(用户查询) == count(所有用户) ? 显示总体 : 返回0
 				// 总用户数通过count(count(net_user_rx_packets) by (username))获取
 				newExpr := "(count(sum(rate(net_user_rx_packets{username=~\"$username\"}[1m])) by (username)) == count(count(net_user_rx_packets) by (username))) * rate(net_interface_rx_packets{iface=\"wg0\"}[1m])"
-				target["expr"] = newExpr
+				target.Expr = newExpr
 			case "B":
 				// TX Packets Total：当用户查询匹配的用户数等于总用户数时显示，否则返回0
 				newExpr := "(count(sum(rate(net_user_tx_packets{username=~\"$username\"}[1m])) by (username)) == count(count(net_user_tx_packets) by (username))) * rate(net_interface_tx_packets{iface=\"wg0\"}[1m])"
-				target["expr"] = newExpr
+				target.Expr = newExpr
 			}
 			// refId C和D（用户查询）保持不变，正常显示
 		}
@@ -4,75 +4,189 @@ import (
 	"fmt"
 
 	"tunnel-monitor/internal/config"
+	"tunnel-monitor/internal/grafana"
+	"tunnel-monitor/internal/version"
 )
 
-// CreateBusinessDashboard 创建IPTunnel业务监控面板（包含客户端功能业务）
-func CreateBusinessDashboard() error {
-	fmt.Println("📊 创建IPTunnel业务监控面板...")
+// CreateOptions 创建面板的选项
+type CreateOptions struct {
+	DryRun        bool   // 只渲染并与线上版本比较，不导入 Grafana
+	LibraryPanels bool   // 将panels片段发布为库面板，dashboard 中只保留引用
+	Lenient       bool   // 跳过无法加载的panels片段，只输出警告
+	Env           string // 环境名称，应用 overlays/<环境> 中的覆盖配置
+}
 
-	cfg := config.Global
+// useLibraryPanels 命令行参数或配置开启任意一个即使用库面板
+func (opts CreateOptions) useLibraryPanels() bool {
+	return opts.LibraryPanels || config.Global.Dashboards.LibraryPanels
+}
 
-	// 加载业务模板
-	dashboard, err := LoadBusinessTemplate()
+// CreateDashboard 根据面板清单创建面板
+func CreateDashboard(name string, opts CreateOptions) error {
+	m, err := LoadManifest(name)
 	if err != nil {
-		return fmt.Errorf("加载业务模板失败: %w", err)
+		return err
 	}
 
-	// 设置面板标题和UID
-	dashboard["title"] = "IPTunnel 业务监控"
-	dashboard["uid"] = cfg.Dashboards.BusinessUID
-	if dashboard["uid"] == "" {
-		dashboard["uid"] = "iptunnel-business"
+	return createFromManifest(m, opts)
+}
+
+// createFromManifest 渲染清单对应的面板，dry-run 时只输出差异，否则导入 Grafana
+func createFromManifest(m *Manifest, opts CreateOptions) error {
+	fmt.Printf("📊 创建%s面板...\n", m.Title)
+
+	tm := NewTemplateManager(config.Global.Dashboards.Dir)
+	tm.Lenient = opts.Lenient
+	tm.Env = opts.Env
+	if tm.files.Embedded() {
+		fmt.Printf("📦 %s 不存在，使用内置模板\n", config.Global.Dashboards.Dir)
+	}
+	dashboard, err := renderDashboard(tm, m)
+	if err != nil {
+		return err
+	}
+	libraryPanels, err := finalizeDashboard(dashboard, opts.useLibraryPanels())
+	if err != nil {
+		return err
 	}
 
-	// 修复数据源引用
-	FixDatasource(dashboard)
+	if opts.DryRun {
+		return previewDashboard(dashboard, m, libraryPanels)
+	}
 
 	// 导入到 Grafana
-	if err := ImportDashboard(dashboard); err != nil {
-		return fmt.Errorf("导入面板失败: %w", err)
+	if err := importToFolder(dashboard, m, versionMessage(tm), libraryPanels); err != nil {
+		return err
 	}
 
-	fmt.Println("✅ IPTunnel业务监控面板创建成功")
-	fmt.Println("💡 提示：")
-	fmt.Println("   - 使用'带宽线路'下拉框筛选特定线路")
-	fmt.Println("   - 选择'All'显示所有线路数据")
-	fmt.Println("   - 客户端数据由服务端转发，通过exported_instance标签区分")
+	fmt.Printf("✅ %s面板创建成功\n", m.Title)
+	if len(m.Tips) > 0 {
+		fmt.Println("💡 提示：")
+		for _, tip := range m.Tips {
+			fmt.Printf("   - %s\n", tip)
+		}
+	}
 	return nil
 }
 
-// CreateServerDashboard 创建IPTunnel服务端监控面板（服务端相关 + 统计数据）
-func CreateServerDashboard() error {
-	fmt.Println("📊 创建IPTunnel服务端监控面板...")
+// renderDashboard 按清单渲染面板，得到与导入 Grafana 时完全相同的内容
+func renderDashboard(tm *TemplateManager, m *Manifest) (*Dashboard, error) {
+	dashboard, err := tm.BuildDashboard(m)
+	if err != nil {
+		return nil, fmt.Errorf("组合面板 %s 失败: %w", m.Name, err)
+	}
+
+	// 应用环境覆盖配置，覆盖文件中也可以使用占位符
+	if err := tm.ApplyOverlay(dashboard, m.Name); err != nil {
+		return nil, fmt.Errorf("面板 %s 应用环境 %s 覆盖配置失败: %w", m.Name, tm.Env, err)
+	}
+
+	// 替换数据源UID和 template_vars 占位符
+	if err := ResolvePlaceholders(dashboard); err != nil {
+		return nil, fmt.Errorf("面板 %s: %w", m.Name, err)
+	}
+
+	return dashboard, nil
+}
 
-	cfg := config.Global
+// CreateAllDashboards 创建清单目录中的所有面板
+func CreateAllDashboards(opts CreateOptions) error {
+	fmt.Println("🚀 开始创建监控面板...")
+	fmt.Println()
 
-	// 加载服务端监控模板
-	dashboard, err := LoadServerTemplate()
+	manifests, err := LoadManifests()
 	if err != nil {
-		return fmt.Errorf("加载服务端模板失败: %w", err)
+		return err
+	}
+
+	for _, m := range manifests {
+		if err := createFromManifest(m, opts); err != nil {
+			return fmt.Errorf("面板 %s 创建失败: %w", m.Name, err)
+		}
+		fmt.Println()
 	}
 
-	// 设置面板标题和UID
-	dashboard["title"] = "IPTunnel 服务端监控"
-	dashboard["uid"] = cfg.Dashboards.ServerUID
-	if dashboard["uid"] == "" {
-		dashboard["uid"] = "iptunnel-server-monitoring"
+	if opts.DryRun {
+		fmt.Println("✅ dry-run 完成，未修改任何面板")
+		return nil
 	}
+	fmt.Println("✅ 监控面板创建完成！")
+	return nil
+}
 
-	// 修复数据源引用
-	FixDatasource(dashboard)
+// finalizeDashboard 渲染后的最后处理：按需转换为库面板引用，并移除模板扩展字段
+// 返回需要上传的库面板
+func finalizeDashboard(dashboard *Dashboard, useLibraryPanels bool) ([]LibraryPanel, error) {
+	var libraryPanels []LibraryPanel
+	if useLibraryPanels {
+		var err error
+		if libraryPanels, err = LinkLibraryPanels(dashboard); err != nil {
+			return nil, err
+		}
+	}
 
-	// 导入到 Grafana
-	if err := ImportDashboard(dashboard); err != nil {
+	StripExtensionFields(dashboard)
+	return libraryPanels, nil
+}
+
+// importToFolder 确保面板配置的文件夹存在，上传库面板后导入面板
+func importToFolder(dashboard *Dashboard, m *Manifest, message string, libraryPanels []LibraryPanel) error {
+	folderUID, err := grafana.NewClient().EnsureFolder(dashboardFolder(m))
+	if err != nil {
+		return err
+	}
+
+	// dashboard 引用的库面板必须先存在
+	if len(libraryPanels) > 0 {
+		if err := UploadLibraryPanels(libraryPanels, folderUID); err != nil {
+			return err
+		}
+	}
+
+	if err := ImportDashboard(dashboard, ImportOptions{FolderUID: folderUID, Message: message}); err != nil {
 		return fmt.Errorf("导入面板失败: %w", err)
 	}
 
-	fmt.Println("✅ IPTunnel服务端监控面板创建成功")
-	fmt.Println("💡 提示：")
-	fmt.Println("   - 专注于服务端健康状态、通信状态和业务统计")
-	fmt.Println("   - 使用'实例'下拉框切换不同的服务端")
-	fmt.Println("   - 包含POP通信延迟和服务端性能指标")
+	fmt.Printf("📝 版本说明: %s\n", message)
+	return nil
+}
+
+// versionMessage 生成 Grafana 版本历史中的说明，包含工具版本和模板文件摘要
+// Grafana 的 message 字段最长 255 字符，这里只记录组合摘要和文件数
+func versionMessage(tm *TemplateManager) string {
+	return fmt.Sprintf("tunnel-monitor %s: templates %s (%d files)", version.Version, tm.SourceDigest(), len(tm.Sources()))
+}
+
+// previewDashboard 将渲染结果与 Grafana 中的线上版本比较并输出差异
+func previewDashboard(dashboard *Dashboard, m *Manifest, libraryPanels []LibraryPanel) error {
+	uid := dashboard.UID
+	folder := dashboardFolder(m)
+
+	live, meta, err := GetDashboardByUID(uid)
+	if grafana.IsNotFound(err) {
+		fmt.Printf("🔍 [dry-run] 面板 %s 在 Grafana 中不存在，将新建到文件夹 %s（%d 个面板）\n", uid, folderDisplayName(folder), len(dashboard.AllPanels()))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("获取线上面板失败: %w", err)
+	}
+
+	lines := DiffDashboards(live, dashboard)
+	if len(libraryPanels) > 0 {
+		lines = append(lines, fmt.Sprintf("~ 库面板: 将创建或更新 %d 个", len(libraryPanels)))
+	}
+	if liveFolder := liveFolderTitle(meta); liveFolder != folder {
+		lines = append([]string{fmt.Sprintf("~ 文件夹: %s → %s", folderDisplayName(liveFolder), folderDisplayName(folder))}, lines...)
+	}
+	if len(lines) == 0 {
+		fmt.Printf("🔍 [dry-run] 面板 %s 与线上版本一致\n", uid)
+		return nil
+	}
+
+	fmt.Printf("🔍 [dry-run] 面板 %s 与线上版本的差异：\n", uid)
+	for _, line := range lines {
+		fmt.Println(line)
+	}
 	return nil
 }
 
@@ -91,15 +205,30 @@ func ListDashboards() error {
 		return nil
 	}
 
+	// 按文件夹分组，保持 Grafana 返回的顺序
+	groups := make(map[string][]map[string]interface{})
+	var folders []string
 	for _, db := range dashboards {
-		title := getString(db, "title")
-		uid := getString(db, "uid")
-		url := getString(db, "url")
-
-		fmt.Printf("📋 %s\n", title)
-		fmt.Printf("   UID: %s\n", uid)
-		if url != "" {
-			fmt.Printf("   访问: %s%s\n", config.Global.Grafana.URL, url)
+		folder := getString(db, "folderTitle")
+		if _, ok := groups[folder]; !ok {
+			folders = append(folders, folder)
+		}
+		groups[folder] = append(groups[folder], db)
+	}
+
+	for _, folder := range folders {
+		fmt.Printf("📁 %s\n", folderDisplayName(folder))
+
+		for _, db := range groups[folder] {
+			title := getString(db, "title")
+			uid := getString(db, "uid")
+			url := getString(db, "url")
+
+			fmt.Printf("   📋 %s\n", title)
+			fmt.Printf("      UID: %s\n", uid)
+			if url != "" {
+				fmt.Printf("      访问: %s%s\n", config.Global.Grafana.URL, url)
+			}
 		}
 		fmt.Println()
 	}
@@ -107,22 +236,18 @@ func ListDashboards() error {
 	return nil
 }
 
-// CreateAllDashboards 创建所有监控面板（业务监控面板和服务端监控面板）
-func CreateAllDashboards() error {
-	fmt.Println("🚀 开始创建监控面板...")
-	fmt.Println()
-
-	// 创建业务监控面板
-	if err := CreateBusinessDashboard(); err != nil {
-		return fmt.Errorf("业务面板创建失败: %w", err)
+// liveFolderTitle 从 dashboard 元数据中获取所在文件夹标题，General 返回空字符串
+func liveFolderTitle(meta map[string]interface{}) string {
+	if getString(meta, "folderUid") == "" {
+		return ""
 	}
+	return getString(meta, "folderTitle")
+}
 
-	// 创建服务端监控面板
-	if err := CreateServerDashboard(); err != nil {
-		return fmt.Errorf("服务端面板创建失败: %w", err)
+// folderDisplayName 返回文件夹的显示名称
+func folderDisplayName(folder string) string {
+	if folder == "" {
+		return "General"
 	}
-
-	fmt.Println()
-	fmt.Println("✅ 监控面板创建完成！")
-	return nil
+	return folder
 }
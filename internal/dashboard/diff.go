@@ -0,0 +1,295 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// panelIgnoredFields 比较 panel 时单独处理或不关心的字段
+var panelIgnoredFields = map[string]bool{
+	"id":            true,
+	"gridPos":       true,
+	"targets":       true,
+	"panels":        true,
+	"pluginVersion": true,
+}
+
+// variableIgnoredFields 比较变量时忽略的字段，这些字段由 Grafana 在运行时刷新
+var variableIgnoredFields = map[string]bool{
+	"current": true,
+	"options": true,
+}
+
+// DiffDashboards 比较线上 dashboard 与新渲染的 dashboard
+// 按 panel、target、变量和 gridPos 输出可读的差异，没有差异时返回空切片
+// 按通用 JSON 比较，模型中未建模的字段同样参与比较
+func DiffDashboards(live, rendered *Dashboard) []string {
+	liveDoc := normalizeJSON(live)
+	renderedDoc := normalizeJSON(rendered)
+
+	var lines []string
+
+	for _, key := range []string{"title", "refresh", "tags", "time"} {
+		if !reflect.DeepEqual(liveDoc[key], renderedDoc[key]) {
+			lines = append(lines, fmt.Sprintf("~ %s: %s → %s", key, compactJSON(liveDoc[key]), compactJSON(renderedDoc[key])))
+		}
+	}
+
+	lines = append(lines, diffVariables(liveDoc, renderedDoc)...)
+	lines = append(lines, diffPanels(liveDoc, renderedDoc)...)
+
+	return lines
+}
+
+// diffVariables 按变量名比较 templating.list
+func diffVariables(live, rendered map[string]interface{}) []string {
+	liveVars, liveOrder := indexByKey(templatingList(live), variableKey)
+	newVars, newOrder := indexByKey(templatingList(rendered), variableKey)
+
+	var lines []string
+
+	for _, name := range newOrder {
+		newVar := newVars[name]
+		oldVar, ok := liveVars[name]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("+ 变量 %s", name))
+			continue
+		}
+
+		if changed := changedFields(oldVar, newVar, variableIgnoredFields); len(changed) > 0 {
+			lines = append(lines, fmt.Sprintf("~ 变量 %s", name))
+			for _, field := range changed {
+				lines = append(lines, fmt.Sprintf("    %s: %s → %s", field, compactJSON(oldVar[field]), compactJSON(newVar[field])))
+			}
+		}
+	}
+
+	for _, name := range liveOrder {
+		if _, ok := newVars[name]; !ok {
+			lines = append(lines, fmt.Sprintf("- 变量 %s", name))
+		}
+	}
+
+	return lines
+}
+
+// diffPanels 按标题比较所有 panel（包括 row 和折叠 row 中的 panel）
+func diffPanels(live, rendered map[string]interface{}) []string {
+	livePanels, liveOrder := indexByKey(allPanels(live), panelKey)
+	newPanels, newOrder := indexByKey(allPanels(rendered), panelKey)
+
+	var lines []string
+
+	for _, key := range newOrder {
+		newPanel := newPanels[key]
+		oldPanel, ok := livePanels[key]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("+ 面板 %s (%s) %s", key, getString(newPanel, "type"), formatGridPos(newPanel["gridPos"])))
+			for _, target := range targetsOf(newPanel) {
+				lines = append(lines, fmt.Sprintf("    + target %s: %s", getString(target, "refId"), targetQuery(target)))
+			}
+			continue
+		}
+
+		var details []string
+
+		if !reflect.DeepEqual(oldPanel["gridPos"], newPanel["gridPos"]) {
+			details = append(details, fmt.Sprintf("    gridPos: %s → %s", formatGridPos(oldPanel["gridPos"]), formatGridPos(newPanel["gridPos"])))
+		}
+
+		details = append(details, diffTargets(oldPanel, newPanel)...)
+
+		if changed := changedFields(oldPanel, newPanel, panelIgnoredFields); len(changed) > 0 {
+			details = append(details, fmt.Sprintf("    其他字段变更: %s", strings.Join(changed, ", ")))
+		}
+
+		if len(details) > 0 {
+			lines = append(lines, fmt.Sprintf("~ 面板 %s", key))
+			lines = append(lines, details...)
+		}
+	}
+
+	for _, key := range liveOrder {
+		if _, ok := newPanels[key]; !ok {
+			lines = append(lines, fmt.Sprintf("- 面板 %s (%s)", key, getString(livePanels[key], "type")))
+		}
+	}
+
+	return lines
+}
+
+// diffTargets 按 refId 比较 panel 的查询
+func diffTargets(oldPanel, newPanel map[string]interface{}) []string {
+	oldTargets, oldOrder := indexByKey(targetsOf(oldPanel), targetKey)
+	newTargets, newOrder := indexByKey(targetsOf(newPanel), targetKey)
+
+	var lines []string
+
+	for _, refID := range newOrder {
+		newTarget := newTargets[refID]
+		oldTarget, ok := oldTargets[refID]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("    + target %s: %s", refID, targetQuery(newTarget)))
+			continue
+		}
+
+		if oldQuery, newQuery := targetQuery(oldTarget), targetQuery(newTarget); oldQuery != newQuery {
+			lines = append(lines, fmt.Sprintf("    ~ target %s:", refID))
+			lines = append(lines, fmt.Sprintf("        - %s", oldQuery))
+			lines = append(lines, fmt.Sprintf("        + %s", newQuery))
+		} else if !reflect.DeepEqual(oldTarget, newTarget) {
+			lines = append(lines, fmt.Sprintf("    ~ target %s: %s", refID, strings.Join(changedFields(oldTarget, newTarget, nil), ", ")))
+		}
+	}
+
+	for _, refID := range oldOrder {
+		if _, ok := newTargets[refID]; !ok {
+			lines = append(lines, fmt.Sprintf("    - target %s: %s", refID, targetQuery(oldTargets[refID])))
+		}
+	}
+
+	return lines
+}
+
+// templatingList 返回 dashboard 的变量列表
+func templatingList(dashboard map[string]interface{}) []map[string]interface{} {
+	templating, ok := dashboard["templating"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	list, _ := templating["list"].([]interface{})
+	return toMaps(list)
+}
+
+// allPanels 返回 dashboard 中的所有 panel，折叠 row 中的 panel 也会展开
+func allPanels(dashboard map[string]interface{}) []map[string]interface{} {
+	panels, _ := dashboard["panels"].([]interface{})
+
+	var result []map[string]interface{}
+	for _, panel := range toMaps(panels) {
+		result = append(result, panel)
+		if panel["type"] == "row" {
+			children, _ := panel["panels"].([]interface{})
+			result = append(result, toMaps(children)...)
+		}
+	}
+	return result
+}
+
+// targetsOf 返回 panel 的查询列表
+func targetsOf(panel map[string]interface{}) []map[string]interface{} {
+	targets, _ := panel["targets"].([]interface{})
+	return toMaps(targets)
+}
+
+// targetQuery 返回 target 的查询语句（PromQL 或 SQL）
+func targetQuery(target map[string]interface{}) string {
+	if expr := getString(target, "expr"); expr != "" {
+		return expr
+	}
+	return getString(target, "rawSql")
+}
+
+func variableKey(v map[string]interface{}) string {
+	return getString(v, "name")
+}
+
+func targetKey(t map[string]interface{}) string {
+	return getString(t, "refId")
+}
+
+func panelKey(p map[string]interface{}) string {
+	if title := getString(p, "title"); title != "" {
+		if p["type"] == "row" {
+			return fmt.Sprintf("[row] %q", title)
+		}
+		return fmt.Sprintf("%q", title)
+	}
+	return fmt.Sprintf("#%v", p["id"])
+}
+
+// indexByKey 按 key 建立索引并保留原始顺序，重复的 key 追加序号区分
+func indexByKey(items []map[string]interface{}, keyFn func(map[string]interface{}) string) (map[string]map[string]interface{}, []string) {
+	index := make(map[string]map[string]interface{})
+	var order []string
+
+	for _, item := range items {
+		key := keyFn(item)
+		for n := 2; ; n++ {
+			if _, exists := index[key]; !exists {
+				break
+			}
+			key = fmt.Sprintf("%s#%d", keyFn(item), n)
+		}
+		index[key] = item
+		order = append(order, key)
+	}
+
+	return index, order
+}
+
+// changedFields 返回两个对象中值不同的字段名（按字母排序）
+func changedFields(oldObj, newObj map[string]interface{}, ignored map[string]bool) []string {
+	keys := make(map[string]bool)
+	for k := range oldObj {
+		keys[k] = true
+	}
+	for k := range newObj {
+		keys[k] = true
+	}
+
+	var changed []string
+	for k := range keys {
+		if ignored[k] {
+			continue
+		}
+		if !reflect.DeepEqual(oldObj[k], newObj[k]) {
+			changed = append(changed, k)
+		}
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+func toMaps(items []interface{}) []map[string]interface{} {
+	var result []map[string]interface{}
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func formatGridPos(v interface{}) string {
+	pos, ok := v.(map[string]interface{})
+	if !ok {
+		return "{}"
+	}
+	return fmt.Sprintf("{x:%v y:%v w:%v h:%v}", pos["x"], pos["y"], pos["w"], pos["h"])
+}
+
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	if runes := []rune(string(data)); len(runes) > 120 {
+		return string(runes[:117]) + "..."
+	}
+	return string(data)
+}
+
+// normalizeJSON 将 dashboard 转换为通用 JSON 对象，数值统一为 float64，避免 int 与 float64 比较时误报差异
+func normalizeJSON(dashboard *Dashboard) map[string]interface{} {
+	v, err := toJSONValue(dashboard)
+	if err != nil {
+		return nil
+	}
+	result, _ := v.(map[string]interface{})
+	return result
+}
@@ -0,0 +1,118 @@
+package dashboard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mustParseDashboard 解析测试用例中的 dashboard JSON
+func mustParseDashboard(t *testing.T, s string) *Dashboard {
+	t.Helper()
+
+	dashboard, err := ParseDashboard([]byte(s))
+	if err != nil {
+		t.Fatalf("ParseDashboard: %v", err)
+	}
+	return dashboard
+}
+
+func TestDiffDashboards(t *testing.T) {
+	tests := []struct {
+		name     string
+		live     string
+		rendered string
+		want     []string
+	}{
+		{
+			name:     "没有差异",
+			live:     `{"title": "a", "panels": [{"id": 1, "type": "stat", "title": "p", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}}]}`,
+			rendered: `{"title": "a", "panels": [{"id": 2, "type": "stat", "title": "p", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}, "pluginVersion": "11.0.0"}]}`,
+		},
+		{
+			name:     "顶层字段",
+			live:     `{"title": "a", "refresh": "10s", "tags": ["x"]}`,
+			rendered: `{"title": "b", "refresh": "10s", "tags": ["x", "y"]}`,
+			want: []string{
+				`~ title: "a" → "b"`,
+				`~ tags: ["x"] → ["x","y"]`,
+			},
+		},
+		{
+			name: "变量增删改，忽略 current 和 options",
+			live: `{"templating": {"list": [
+				{"name": "pop", "type": "query", "query": "label_values(up, instance)", "current": {"value": "a"}},
+				{"name": "old", "type": "custom"}
+			]}}`,
+			rendered: `{"templating": {"list": [
+				{"name": "pop", "type": "query", "query": "label_values(pop_alive_status, instance)", "current": {"value": "b"}, "options": []},
+				{"name": "new", "type": "textbox"}
+			]}}`,
+			want: []string{
+				"~ 变量 pop",
+				`    query: "label_values(up, instance)" → "label_values(pop_alive_status, instance)"`,
+				"+ 变量 new",
+				"- 变量 old",
+			},
+		},
+		{
+			name: "panel 增删和 gridPos",
+			live: `{"panels": [
+				{"id": 1, "type": "stat", "title": "a", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+				{"id": 2, "type": "table", "title": "b"}
+			]}`,
+			rendered: `{"panels": [
+				{"id": 1, "type": "stat", "title": "a", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 4}},
+				{"id": 3, "type": "timeseries", "title": "c", "gridPos": {"x": 0, "y": 8, "w": 24, "h": 8}, "targets": [{"refId": "A", "expr": "up"}]}
+			]}`,
+			want: []string{
+				`~ 面板 "a"`,
+				"    gridPos: {x:0 y:0 w:12 h:8} → {x:12 y:0 w:12 h:4}",
+				`+ 面板 "c" (timeseries) {x:0 y:8 w:24 h:8}`,
+				"    + target A: up",
+				`- 面板 "b" (table)`,
+			},
+		},
+		{
+			name: "target 查询和其他字段",
+			live: `{"panels": [{"type": "timeseries", "title": "a", "unit": "ms", "targets": [
+				{"refId": "A", "expr": "up"},
+				{"refId": "B", "rawSql": "SELECT 1", "format": "table"},
+				{"refId": "C", "expr": "down"}
+			]}]}`,
+			rendered: `{"panels": [{"type": "timeseries", "title": "a", "unit": "s", "targets": [
+				{"refId": "A", "expr": "up{job=\"x\"}"},
+				{"refId": "B", "rawSql": "SELECT 1", "format": "time_series"},
+				{"refId": "D", "expr": "sideways"}
+			]}]}`,
+			want: []string{
+				`~ 面板 "a"`,
+				"    ~ target A:",
+				"        - up",
+				`        + up{job="x"}`,
+				"    ~ target B: format",
+				"    + target D: sideways",
+				"    - target C: down",
+				"    其他字段变更: unit",
+			},
+		},
+		{
+			name:     "折叠 row 中的 panel 和同名 panel",
+			live:     `{"panels": [{"type": "row", "title": "r", "collapsed": true, "panels": [{"type": "stat", "title": "x"}, {"type": "stat", "title": "x"}]}]}`,
+			rendered: `{"panels": [{"type": "row", "title": "r", "collapsed": true, "panels": [{"type": "stat", "title": "x"}, {"type": "gauge", "title": "x"}]}]}`,
+			want: []string{
+				`~ 面板 "x"#2`,
+				"    其他字段变更: type",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffDashboards(mustParseDashboard(t, tt.live), mustParseDashboard(t, tt.rendered))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("差异:\n%s\n期望:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -3,46 +3,32 @@ package dashboard
 import (
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
+	"net/url"
 
-	"tunnel-monitor/internal/config"
+	"tunnel-monitor/internal/grafana"
 )
 
-// ImportDashboard 将 dashboard 导入到 Grafana
-func ImportDashboard(dashboard map[string]interface{}) error {
-	cfg := config.Global
+// ImportOptions 导入 dashboard 的选项
+type ImportOptions struct {
+	FolderUID string // 目标文件夹UID，空表示 General
+	Message   string // 版本说明，显示在 Grafana 版本历史中
+}
 
+// ImportDashboard 将 dashboard 导入到 Grafana
+func ImportDashboard(dashboard *Dashboard, opts ImportOptions) error {
 	payload := map[string]interface{}{
 		"dashboard": dashboard,
 		"overwrite": true,
 	}
-
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return err
+	if opts.FolderUID != "" {
+		payload["folderUid"] = opts.FolderUID
 	}
-
-	url := fmt.Sprintf("%s/api/dashboards/db", cfg.Grafana.URL)
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(data)))
-	if err != nil {
-		return err
+	if opts.Message != "" {
+		payload["message"] = opts.Message
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(cfg.Grafana.Username, cfg.Grafana.Password)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("导入失败: %s - %s", resp.Status, string(body))
+	if err := grafana.NewClient().Post("/api/dashboards/db", payload, nil); err != nil {
+		return fmt.Errorf("导入失败: %w", err)
 	}
 
 	return nil
@@ -50,32 +36,33 @@ func ImportDashboard(dashboard map[string]interface{}) error {
 
 // GetDashboards 从 Grafana 获取所有 dashboard 列表
 func GetDashboards() ([]map[string]interface{}, error) {
-	cfg := config.Global
-
-	url := fmt.Sprintf("%s/api/search?type=dash-db", cfg.Grafana.URL)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
+	var dashboards []map[string]interface{}
+	if err := grafana.NewClient().Get("/api/search?type=dash-db", &dashboards); err != nil {
 		return nil, err
 	}
 
-	req.SetBasicAuth(cfg.Grafana.Username, cfg.Grafana.Password)
+	return dashboards, nil
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+// GetDashboardByUID 从 Grafana 获取指定 UID 的 dashboard 及其元数据
+func GetDashboardByUID(uid string) (*Dashboard, map[string]interface{}, error) {
+	var result struct {
+		Dashboard json.RawMessage        `json:"dashboard"`
+		Meta      map[string]interface{} `json:"meta"`
 	}
-	defer resp.Body.Close()
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if err := grafana.NewClient().Get("/api/dashboards/uid/"+url.PathEscape(uid), &result); err != nil {
+		return nil, nil, err
 	}
 
-	var dashboards []map[string]interface{}
-	if err := json.Unmarshal(data, &dashboards); err != nil {
-		return nil, err
+	if len(result.Dashboard) == 0 || string(result.Dashboard) == "null" {
+		return nil, nil, fmt.Errorf("dashboard %s 响应中没有 dashboard 字段", uid)
 	}
 
-	return dashboards, nil
+	dashboard, err := ParseDashboard(result.Dashboard)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析 dashboard %s 失败: %w", uid, err)
+	}
+
+	return dashboard, result.Meta, nil
 }
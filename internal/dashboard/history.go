@@ -0,0 +1,92 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"tunnel-monitor/internal/grafana"
+)
+
+// DashboardVersion Grafana 中 dashboard 的一个历史版本
+type DashboardVersion struct {
+	Version   int       `json:"version"`
+	Created   time.Time `json:"created"`
+	CreatedBy string    `json:"createdBy"`
+	Message   string    `json:"message"`
+}
+
+// GetDashboardVersions 获取 dashboard 的历史版本，按版本号从新到旧排列
+func GetDashboardVersions(uid string, limit int) ([]DashboardVersion, error) {
+	path := fmt.Sprintf("/api/dashboards/uid/%s/versions?limit=%d", url.PathEscape(uid), limit)
+
+	var raw json.RawMessage
+	if err := grafana.NewClient().Get(path, &raw); err != nil {
+		return nil, err
+	}
+
+	// Grafana 11 之前直接返回数组，之后返回 {"versions": [...], "continueToken": ""}
+	var versions []DashboardVersion
+	if err := json.Unmarshal(raw, &versions); err == nil {
+		return versions, nil
+	}
+
+	var paged struct {
+		Versions []DashboardVersion `json:"versions"`
+	}
+	if err := json.Unmarshal(raw, &paged); err != nil {
+		return nil, fmt.Errorf("解析版本列表失败: %w", err)
+	}
+
+	return paged.Versions, nil
+}
+
+// ShowDashboardHistory 列出 dashboard 的历史版本
+func ShowDashboardHistory(uid string, limit int) error {
+	versions, err := GetDashboardVersions(uid, limit)
+	if err != nil {
+		return fmt.Errorf("获取面板 %s 的版本历史失败: %w", uid, err)
+	}
+
+	if len(versions) == 0 {
+		fmt.Printf("面板 %s 没有历史版本\n", uid)
+		return nil
+	}
+
+	fmt.Printf("📜 面板 %s 的版本历史:\n", uid)
+	fmt.Println()
+
+	for _, v := range versions {
+		message := v.Message
+		if message == "" {
+			message = "-"
+		}
+		fmt.Printf("v%-4d %s  %-12s %s\n", v.Version, v.Created.Local().Format("2006-01-02 15:04:05"), v.CreatedBy, message)
+	}
+
+	return nil
+}
+
+// RollbackDashboard 将 dashboard 恢复到指定版本
+// Grafana 会以恢复的内容生成一个新版本，原有历史保持不变
+func RollbackDashboard(uid string, version int) error {
+	if version <= 0 {
+		return fmt.Errorf("请使用 --version 指定要恢复的版本号")
+	}
+
+	fmt.Printf("⏪ 恢复面板 %s 到版本 %d...\n", uid, version)
+
+	path := fmt.Sprintf("/api/dashboards/uid/%s/restore", url.PathEscape(uid))
+	payload := map[string]int{"version": version}
+
+	var result struct {
+		Version int `json:"version"`
+	}
+	if err := grafana.NewClient().Post(path, payload, &result); err != nil {
+		return fmt.Errorf("恢复面板失败: %w", err)
+	}
+
+	fmt.Printf("✅ 面板 %s 已恢复到版本 %d（当前版本 v%d）\n", uid, version, result.Version)
+	return nil
+}
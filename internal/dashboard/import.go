@@ -0,0 +1,176 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+	"tunnel-monitor/internal/config"
+)
+
+// exportFields Grafana 导出（Export for sharing externally）时添加的字段，不应写入模板
+var exportFields = []string{"__inputs", "__requires", "__elements"}
+
+// templateNamePattern import-file 的模板名称，同时用作文件名和清单名称
+var templateNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ImportFileOptions dashboard import-file 的选项
+type ImportFileOptions struct {
+	Name string // 模板名称：输出到 <name>-base.json、panels/<name> 和 manifests/<name>.yaml
+}
+
+// ImportDashboardFile 将从 Grafana 界面导出的 dashboard JSON 拆分为基础模板、panels片段和面板清单
+// 已有同名面板清单时写回清单引用的基础模板和panels目录，清单保持不变
+func ImportDashboardFile(path string, opts ImportFileOptions) error {
+	if !templateNamePattern.MatchString(opts.Name) {
+		return fmt.Errorf("模板名称 %q 只能包含小写字母、数字、- 和 _", opts.Name)
+	}
+	fmt.Printf("📥 导入面板文件 %s...\n", path)
+
+	basePath, panelsDir, err := resolvePullTarget(path, PullOptions{Name: opts.Name})
+	if err != nil {
+		return err
+	}
+
+	// 清单可能来自内置模板，按读取清单时的规则查找，已有的清单（包括其中的 uid）保持不变
+	dir := config.Global.Dashboards.Dir
+	manifestPath := filepath.Join(manifestsDir(), opts.Name+".yaml")
+	existing, err := openTemplateFiles(dir).Glob(filepath.Join(manifestsDir(), opts.Name+".y*ml"))
+	if err != nil {
+		return err
+	}
+	manifestExists := len(existing) > 0
+	if manifestExists {
+		manifestPath = existing[0]
+	} else {
+		if err := checkUIDAvailable(opts.Name); err != nil {
+			return err
+		}
+	}
+
+	tm := NewTemplateManager("")
+	tm.ASCIIFileNames = true
+	m, result, err := tm.SplitTemplate(path, basePath, panelsDir, dir)
+	if err != nil {
+		return fmt.Errorf("拆分面板失败: %w", err)
+	}
+
+	fmt.Printf("✅ 基础模板: %s\n", basePath)
+	fmt.Printf("✅ panels目录: %s（%d 个片段）\n", panelsDir, len(result.Files))
+	for _, file := range result.Stale {
+		fmt.Printf("⚠️ %s 在导入的面板中不存在，如已删除请手动移除该文件\n", file)
+	}
+
+	if manifestExists {
+		fmt.Printf("ℹ️  面板清单 %s 已存在，未修改\n", manifestPath)
+	} else {
+		if err := writeManifest(manifestPath, m, path); err != nil {
+			return err
+		}
+		fmt.Printf("✅ 面板清单: %s\n", manifestPath)
+	}
+
+	fmt.Printf("💡 检查无误后可以删除 %s，使用 dashboard create-from %s 创建面板\n", path, opts.Name)
+	return nil
+}
+
+// checkUIDAvailable 新清单的面板UID为模板名称，不能与已有清单的面板UID相同
+func checkUIDAvailable(name string) error {
+	manifests, err := LoadManifests()
+	if err != nil {
+		return err
+	}
+	for _, m := range manifests {
+		if dashboardUID(m) == name {
+			return fmt.Errorf("面板UID %s 已被面板清单 %s 使用，请使用其他 --name", name, m.file)
+		}
+	}
+	return nil
+}
+
+// normalizeExport 规范化从 Grafana 获取或导出的 dashboard
+// 移除 __inputs、__requires 和 Grafana 维护的字段，将数据源输入（${DS_PROMETHEUS}）和具体的数据源UID还原为占位符
+func normalizeExport(dashboard *Dashboard) {
+	// 数据源输入 -> 数据源引用，如 ${DS_PROMETHEUS} -> {type: prometheus, uid: {{PROMETHEUS_UID}}}
+	inputs := make(map[string]datasourceRef)
+	list, _ := dashboard.Extra["__inputs"].([]interface{})
+	for _, item := range list {
+		input, ok := item.(map[string]interface{})
+		if !ok || getString(input, "type") != "datasource" {
+			continue
+		}
+		dsType := getString(input, "pluginId")
+		if placeholder := typePlaceholder(dsType); placeholder != "" {
+			inputs["${"+getString(input, "name")+"}"] = datasourceRef{Type: dsType, UID: placeholder}
+		}
+	}
+
+	for _, field := range append(exportFields, volatileFields...) {
+		delete(dashboard.Extra, field)
+	}
+
+	if len(inputs) > 0 {
+		for _, extra := range dashboard.extras() {
+			restoreDatasourceInputs(extra, inputs)
+		}
+	}
+
+	RestoreDatasourcePlaceholders(dashboard)
+}
+
+// datasourceRef 数据源引用
+type datasourceRef struct {
+	Type string
+	UID  string
+}
+
+// restoreDatasourceInputs 将引用数据源输入的 datasource 替换为占位符
+// 旧版本导出的 datasource 是字符串（"${DS_PROMETHEUS}"），替换为包含 type 和 uid 的对象
+func restoreDatasourceInputs(obj interface{}, inputs map[string]datasourceRef) {
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		switch ds := v["datasource"].(type) {
+		case map[string]interface{}:
+			if ref, ok := inputs[getString(ds, "uid")]; ok {
+				ds["uid"] = ref.UID
+			}
+		case string:
+			if ref, ok := inputs[ds]; ok {
+				v["datasource"] = map[string]interface{}{"type": ref.Type, "uid": ref.UID}
+			}
+		}
+		for _, val := range v {
+			restoreDatasourceInputs(val, inputs)
+		}
+	case []interface{}:
+		for _, item := range v {
+			restoreDatasourceInputs(item, inputs)
+		}
+	}
+}
+
+// writeManifest 写入导入时生成的面板清单，缩进与仓库中的清单一致
+func writeManifest(path string, m *Manifest, source string) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s（由 dashboard import-file 从 %s 生成）\n", m.Title, filepath.Base(source))
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(m); err != nil {
+		return fmt.Errorf("生成面板清单失败: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("生成面板清单失败: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建面板清单目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("保存面板清单失败: %w", err)
+	}
+	return nil
+}
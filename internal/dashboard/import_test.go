@@ -0,0 +1,129 @@
+package dashboard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tunnel-monitor/internal/config"
+)
+
+// exportedBusiness 从 Grafana 导出的业务监控面板，uid 与内置的 business 清单相同
+const exportedBusiness = `{
+	"uid": "iptunnel-business",
+	"title": "IPTunnel 业务监控（副本）",
+	"panels": [{"id": 1, "type": "timeseries", "title": "POP alive", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}}]
+}`
+
+// setDashboardsDir 使用临时目录作为模板目录，目录中的文件优先于内置模板
+func setDashboardsDir(t *testing.T) string {
+	t.Helper()
+
+	previous := config.Global
+	t.Cleanup(func() { config.Global = previous })
+
+	dir := t.TempDir()
+	config.Global = &config.Config{}
+	config.Global.Dashboards.Dir = dir
+	config.Global.Features = map[string]bool{"MySQL": true}
+	return dir
+}
+
+func TestImportDashboardFileUsesNameAsUID(t *testing.T) {
+	dir := setDashboardsDir(t)
+
+	path := filepath.Join(t.TempDir(), "export.json")
+	if err := os.WriteFile(path, []byte(exportedBusiness), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportDashboardFile(path, ImportFileOptions{Name: "business-copy"}); err != nil {
+		t.Fatalf("ImportDashboardFile: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestsDirName, "business-copy.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "uid:") {
+		t.Errorf("生成的清单不应包含导出文件的 uid:\n%s", data)
+	}
+
+	m, err := LoadManifest("business-copy")
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	if uid := dashboardUID(m); uid != "business-copy" {
+		t.Errorf("面板UID = %s，期望 business-copy", uid)
+	}
+}
+
+func TestImportDashboardFileRejectsTakenUID(t *testing.T) {
+	dir := setDashboardsDir(t)
+
+	path := filepath.Join(t.TempDir(), "export.json")
+	if err := os.WriteFile(path, []byte(exportedBusiness), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 内置的 business 清单使用 iptunnel-business 作为面板UID
+	err := ImportDashboardFile(path, ImportFileOptions{Name: "iptunnel-business"})
+	if err == nil || !strings.Contains(err.Error(), "已被面板清单") {
+		t.Fatalf("错误 = %v，期望面板UID已被使用", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "iptunnel-business-base.json")); !os.IsNotExist(err) {
+		t.Errorf("拒绝导入时不应写入基础模板: %v", err)
+	}
+}
+
+func TestImportDashboardFileKeepsExistingManifest(t *testing.T) {
+	dir := setDashboardsDir(t)
+
+	manifest := "uid: custom-uid\ntitle: 定制面板\nbase: custom-base.json\npanels:\n  - panels/custom/*.json\n"
+	manifestPath := filepath.Join(dir, manifestsDirName, "custom.yaml")
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "export.json")
+	if err := os.WriteFile(path, []byte(exportedBusiness), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportDashboardFile(path, ImportFileOptions{Name: "custom"}); err != nil {
+		t.Fatalf("ImportDashboardFile: %v", err)
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != manifest {
+		t.Errorf("已有的清单被修改为:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "panels", "custom")); err != nil {
+		t.Errorf("片段应写入清单引用的目录: %v", err)
+	}
+}
+
+func TestImportDashboardFileRefusesEmbeddedDirs(t *testing.T) {
+	dir := setDashboardsDir(t)
+
+	path := filepath.Join(t.TempDir(), "export.json")
+	if err := os.WriteFile(path, []byte(exportedBusiness), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模板目录为空时 business 清单和 panels/client 都来自内置模板
+	err := ImportDashboardFile(path, ImportFileOptions{Name: "business"})
+	if err == nil || !strings.Contains(err.Error(), "templates extract") {
+		t.Fatalf("错误 = %v，期望提示先导出内置模板", err)
+	}
+	for _, name := range []string{"panels", manifestsDirName, "business-base.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("拒绝导入时不应写入 %s: %v", name, err)
+		}
+	}
+}
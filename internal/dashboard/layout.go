@@ -0,0 +1,119 @@
+package dashboard
+
+// Grafana 面板网格宽度为 24 列
+const gridColumns = 24
+
+// 片段未声明宽高时使用的默认值
+const (
+	defaultPanelWidth  = 12
+	defaultPanelHeight = 8
+)
+
+// gridLayout 记录每一列当前的底部位置，面板从左到右依次放置
+type gridLayout struct {
+	heights [gridColumns]int
+	x       int
+}
+
+// newGridLayout 创建从 y 开始布局的网格
+func newGridLayout(y int) *gridLayout {
+	l := &gridLayout{}
+	for i := range l.heights {
+		l.heights[i] = y
+	}
+	return l
+}
+
+// bottom 返回所有列中最低的底部位置
+func (l *gridLayout) bottom() int {
+	bottom := 0
+	for _, h := range l.heights {
+		if h > bottom {
+			bottom = h
+		}
+	}
+	return bottom
+}
+
+// place 按面板声明的宽高放置面板，超过 24 列时换行
+// 面板紧贴所占各列中最低的底部，不会与已放置的面板重叠
+func (l *gridLayout) place(w, h int) (int, int) {
+	if l.x+w > gridColumns {
+		l.x = 0
+	}
+
+	y := 0
+	for i := l.x; i < l.x+w; i++ {
+		if l.heights[i] > y {
+			y = l.heights[i]
+		}
+	}
+
+	x := l.x
+	for i := x; i < x+w; i++ {
+		l.heights[i] = y + h
+	}
+	l.x += w
+
+	return x, y
+}
+
+// placeRow 将 row 标题放在所有面板下方，占满整行
+func (l *gridLayout) placeRow() int {
+	y := l.bottom()
+	for i := range l.heights {
+		l.heights[i] = y + 1
+	}
+	l.x = 0
+	return y
+}
+
+// layoutPanels 根据面板声明的宽高重新计算 gridPos
+// row 标题占一行，展开的 row 之后的面板依次排列；折叠的 row 的子面板放在 row.panels 中，
+// 从 row 标题下方开始单独布局，不占用 dashboard 的位置
+func layoutPanels(panels []*Panel) {
+	l := newGridLayout(0)
+
+	for _, panel := range panels {
+		if !panel.IsRow() {
+			placePanel(l, panel)
+			continue
+		}
+
+		y := l.placeRow()
+		panel.GridPos = &GridPos{X: 0, Y: y, W: gridColumns, H: 1}
+
+		if panel.Collapsed {
+			rowLayout := newGridLayout(y + 1)
+			for _, child := range panel.Panels {
+				placePanel(rowLayout, child)
+			}
+		}
+	}
+}
+
+// placePanel 放置单个面板并写回 gridPos
+func placePanel(l *gridLayout, panel *Panel) {
+	w, h := panelSize(panel)
+	x, y := l.place(w, h)
+	panel.GridPos = &GridPos{X: x, Y: y, W: w, H: h}
+}
+
+// panelSize 返回面板片段声明的宽高，缺失或越界时使用默认值
+func panelSize(panel *Panel) (int, int) {
+	w, h := defaultPanelWidth, defaultPanelHeight
+
+	if pos := panel.GridPos; pos != nil {
+		if pos.W > 0 {
+			w = pos.W
+		}
+		if pos.H > 0 {
+			h = pos.H
+		}
+	}
+
+	if w > gridColumns {
+		w = gridColumns
+	}
+	return w, h
+}
@@ -0,0 +1,91 @@
+package dashboard
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sized 创建声明了宽高的面板，w、h 为 0 时表示未声明
+func sized(w, h int) *Panel {
+	p := &Panel{Type: "timeseries"}
+	if w > 0 || h > 0 {
+		p.GridPos = &GridPos{W: w, H: h}
+	}
+	return p
+}
+
+// row 创建 row，children 为折叠 row 中的子面板
+func row(collapsed bool, children ...*Panel) *Panel {
+	return &Panel{Type: "row", Collapsed: collapsed, Panels: children}
+}
+
+// gridPositions 按顺序返回所有 panel（包括 row 中的子面板）的 {x, y, w, h}
+func gridPositions(panels []*Panel) [][4]int {
+	var result [][4]int
+	for _, p := range panels {
+		pos := p.GridPos
+		result = append(result, [4]int{pos.X, pos.Y, pos.W, pos.H})
+		result = append(result, gridPositions(p.Panels)...)
+	}
+	return result
+}
+
+func TestLayoutPanels(t *testing.T) {
+	tests := []struct {
+		name   string
+		panels []*Panel
+		want   [][4]int
+	}{
+		{
+			name:   "默认宽高，超过 24 列换行",
+			panels: []*Panel{sized(0, 0), sized(0, 0), sized(0, 0)},
+			want:   [][4]int{{0, 0, 12, 8}, {12, 0, 12, 8}, {0, 8, 12, 8}},
+		},
+		{
+			name:   "宽度超过 24 列时占满整行",
+			panels: []*Panel{sized(30, 5), sized(6, 0)},
+			want:   [][4]int{{0, 0, 24, 5}, {0, 5, 6, 8}},
+		},
+		{
+			name:   "换行后紧贴所占列的底部",
+			panels: []*Panel{sized(12, 4), sized(12, 8), sized(12, 4), sized(24, 2)},
+			want:   [][4]int{{0, 0, 12, 4}, {12, 0, 12, 8}, {0, 4, 12, 4}, {0, 8, 24, 2}},
+		},
+		{
+			name:   "忽略片段中的 x 和 y",
+			panels: []*Panel{{GridPos: &GridPos{X: 5, Y: 7, W: 6, H: 3}}},
+			want:   [][4]int{{0, 0, 6, 3}},
+		},
+		{
+			name:   "展开的 row 放在上一组面板下方",
+			panels: []*Panel{sized(8, 4), sized(8, 6), row(false), sized(24, 3), row(false), sized(0, 0)},
+			want: [][4]int{
+				{0, 0, 8, 4}, {8, 0, 8, 6},
+				{0, 6, 24, 1}, {0, 7, 24, 3},
+				{0, 10, 24, 1}, {0, 11, 12, 8},
+			},
+		},
+		{
+			name: "折叠的 row 的子面板从 row 下方单独布局，不占用位置",
+			panels: []*Panel{
+				row(true, sized(0, 0), sized(0, 0), sized(24, 4)),
+				row(true, sized(6, 2)),
+				sized(0, 0),
+			},
+			want: [][4]int{
+				{0, 0, 24, 1}, {0, 1, 12, 8}, {12, 1, 12, 8}, {0, 9, 24, 4},
+				{0, 1, 24, 1}, {0, 2, 6, 2},
+				{0, 2, 12, 8},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layoutPanels(tt.panels)
+			if got := gridPositions(tt.panels); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("gridPos = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package dashboard
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"tunnel-monitor/internal/grafana"
+)
+
+// sourceField 模板管理器在加载片段时记录来源文件的字段，导入 Grafana 前会被移除
+const sourceField = "x-source"
+
+// LibraryPanel 由 panel 片段生成的 Grafana 库面板
+type LibraryPanel struct {
+	UID   string
+	Name  string
+	Model map[string]interface{}
+}
+
+// sourceKey 返回片段来源的稳定标识，只使用所在目录名和文件名，与仓库的存放位置无关
+func sourceKey(source string) string {
+	return filepath.ToSlash(filepath.Join(filepath.Base(filepath.Dir(source)), filepath.Base(source)))
+}
+
+// libraryPanelUID 根据片段文件名生成稳定的库面板UID，source 可以带有 @清单名称 区分添加了链接的库面板
+func libraryPanelUID(source string) string {
+	sum := sha1.Sum([]byte(sourceKey(source)))
+	return "tm-panel-" + hex.EncodeToString(sum[:])[:16]
+}
+
+// LinkLibraryPanels 将 dashboard 中来自片段文件的 panel 替换为库面板引用
+// 返回需要上传的库面板，同一片段在多个位置出现时只返回一次
+func LinkLibraryPanels(dashboard *Dashboard) ([]LibraryPanel, error) {
+	var libraryPanels []LibraryPanel
+	seen := make(map[string]bool)
+
+	var link func(panels []*Panel) error
+	link = func(panels []*Panel) error {
+		for i, panel := range panels {
+			if panel.IsRow() {
+				if err := link(panel.Panels); err != nil {
+					return err
+				}
+				continue
+			}
+
+			source := panel.extraString(sourceField)
+			if source == "" {
+				continue
+			}
+
+			name := panel.Title
+			if name == "" {
+				name = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+			}
+
+			// 清单为片段添加了链接时按清单生成单独的库面板，避免其他面板中的同一片段覆盖这些链接
+			if manifest := panel.extraString(linksField); manifest != "" {
+				source += "@" + manifest
+				name = fmt.Sprintf("%s（%s）", name, manifest)
+			}
+			uid := libraryPanelUID(source)
+
+			if !seen[uid] {
+				seen[uid] = true
+
+				model, err := libraryPanelModel(panel)
+				if err != nil {
+					return fmt.Errorf("生成库面板 %s 失败: %w", name, err)
+				}
+				libraryPanels = append(libraryPanels, LibraryPanel{UID: uid, Name: name, Model: model})
+			}
+
+			panels[i] = &Panel{
+				ID:      panel.ID,
+				Title:   name,
+				GridPos: panel.GridPos,
+				Extra: map[string]interface{}{
+					"libraryPanel": map[string]interface{}{
+						"uid":  uid,
+						"name": name,
+					},
+				},
+			}
+		}
+		return nil
+	}
+
+	if err := link(dashboard.Panels); err != nil {
+		return nil, err
+	}
+	return libraryPanels, nil
+}
+
+// libraryPanelModel 返回库面板的 model：去掉位置、ID 和模板扩展字段的 panel
+func libraryPanelModel(panel *Panel) (map[string]interface{}, error) {
+	v, err := toJSONValue(panel)
+	if err != nil {
+		return nil, err
+	}
+
+	model, _ := v.(map[string]interface{})
+	for k := range model {
+		if k == "id" || k == "gridPos" || strings.HasPrefix(k, "x-") {
+			delete(model, k)
+		}
+	}
+	return model, nil
+}
+
+// UploadLibraryPanels 在 Grafana 中创建或更新库面板
+func UploadLibraryPanels(libraryPanels []LibraryPanel, folderUID string) error {
+	client := grafana.NewClient()
+
+	for _, lp := range libraryPanels {
+		path := "/api/library-elements/" + url.PathEscape(lp.UID)
+
+		var existing struct {
+			Result struct {
+				Version int `json:"version"`
+			} `json:"result"`
+		}
+
+		err := client.Get(path, &existing)
+		if err != nil && !grafana.IsNotFound(err) {
+			return fmt.Errorf("获取库面板 %s 失败: %w", lp.Name, err)
+		}
+
+		payload := map[string]interface{}{
+			"uid":       lp.UID,
+			"name":      lp.Name,
+			"kind":      1, // 1 表示 panel
+			"model":     lp.Model,
+			"folderUid": folderUID,
+		}
+
+		if grafana.IsNotFound(err) {
+			if err := client.Post("/api/library-elements", payload, nil); err != nil {
+				return fmt.Errorf("创建库面板 %s 失败: %w", lp.Name, err)
+			}
+			continue
+		}
+
+		// 更新时需要带上当前版本号，Grafana 以此做并发控制
+		payload["version"] = existing.Result.Version
+		if err := client.Patch(path, payload, nil); err != nil {
+			return fmt.Errorf("更新库面板 %s 失败: %w", lp.Name, err)
+		}
+	}
+
+	fmt.Printf("📚 已同步 %d 个库面板\n", len(libraryPanels))
+	return nil
+}
+
+// StripExtensionFields 移除 panel 上以 x- 开头的模板扩展字段，这些字段只在组合模板时使用
+func StripExtensionFields(dashboard *Dashboard) {
+	for _, panel := range dashboard.AllPanels() {
+		for k := range panel.Extra {
+			if strings.HasPrefix(k, "x-") {
+				delete(panel.Extra, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package dashboard
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// linksField 记录为 panel 添加链接的面板清单，发布为库面板时按清单区分，导入 Grafana 前会被移除
+const linksField = "x-links"
+
+// ManifestLinks 清单中为一组panels片段配置的链接，组合面板时写入匹配的 panel
+type ManifestLinks struct {
+	Panels     []string   `yaml:"panels"`      // panels片段路径，支持 glob
+	DataLinks  []LinkSpec `yaml:"data_links"`  // 数据链接，点击序列或数据点时显示
+	PanelLinks []LinkSpec `yaml:"panel_links"` // panel 标题栏中的链接
+}
+
+// LinkSpec 一个链接，URL 中可以使用 Grafana 的数据链接变量，如 ${__field.labels.exported_instance}
+type LinkSpec struct {
+	Title       string `yaml:"title"`
+	URL         string `yaml:"url"`
+	TargetBlank bool   `yaml:"target_blank"` // 在新标签页中打开
+	Explore     bool   `yaml:"explore"`      // 在 Explore 中打开 panel 的第一个查询，只能用于 data_links
+}
+
+// validate 检查清单中的链接配置
+func (l ManifestLinks) validate() error {
+	if len(l.Panels) == 0 {
+		return fmt.Errorf("缺少 panels")
+	}
+	for _, link := range l.DataLinks {
+		if link.Title == "" || (link.URL == "" && !link.Explore) {
+			return fmt.Errorf("data_links 中的链接需要 title，以及 url 或 explore")
+		}
+	}
+	for _, link := range l.PanelLinks {
+		if link.Explore {
+			return fmt.Errorf("panel_links 不支持 explore，请放在 data_links 中")
+		}
+		if link.Title == "" || link.URL == "" {
+			return fmt.Errorf("panel_links 中的链接需要 title 和 url")
+		}
+	}
+	return nil
+}
+
+// applyLinks 按清单的 links 为来自匹配片段的 panel 添加数据链接和标题栏链接
+// 片段路径没有匹配任何文件时返回错误；模板条件排除的片段没有对应 panel，不会添加链接
+func (tm *TemplateManager) applyLinks(m *Manifest, panels []*Panel) error {
+	for _, links := range m.Links {
+		for _, pattern := range links.Panels {
+			resolved := tm.resolvePath(pattern)
+			files, err := tm.files.Glob(resolved)
+			if err != nil {
+				return fmt.Errorf("links 中的路径 %s 格式错误: %w", pattern, err)
+			}
+			if len(files) == 0 {
+				return fmt.Errorf("links 中的路径 %s 没有匹配任何panels片段", pattern)
+			}
+
+			for _, panel := range flattenPanels(panels) {
+				if panel.IsRow() || !matchSource(resolved, panel.extraString(sourceField)) {
+					continue
+				}
+				for _, link := range links.DataLinks {
+					addDataLink(panel, link)
+				}
+				for _, link := range links.PanelLinks {
+					addPanelLink(panel, link)
+				}
+				panel.setExtra(linksField, m.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchSource 判断 panel 的来源文件是否匹配 glob，一个文件包含多个 panel 时来源带有 #序号
+func matchSource(pattern, source string) bool {
+	if source == "" {
+		return false
+	}
+	if i := strings.LastIndex(source, "#"); i >= 0 {
+		source = source[:i]
+	}
+	matched, _ := filepath.Match(pattern, source)
+	return matched
+}
+
+// addDataLink 在 fieldConfig.defaults.links 中追加数据链接
+// explore 链接使用 Grafana 的内部链接，在 Explore 中以 panel 的数据源执行第一个查询
+func addDataLink(panel *Panel, spec LinkSpec) {
+	link := map[string]interface{}{
+		"title": spec.Title,
+		"url":   spec.URL,
+	}
+	if spec.TargetBlank {
+		link["targetBlank"] = true
+	}
+	if spec.Explore {
+		if internal := exploreLink(panel); internal != nil {
+			link["internal"] = internal
+		}
+	}
+
+	fieldConfig := childObject(panel.Extra, "fieldConfig")
+	if fieldConfig == nil {
+		fieldConfig = map[string]interface{}{}
+		panel.setExtra("fieldConfig", fieldConfig)
+	}
+	defaults := childObject(fieldConfig, "defaults")
+	if defaults == nil {
+		defaults = map[string]interface{}{}
+		fieldConfig["defaults"] = defaults
+	}
+
+	existing, _ := defaults["links"].([]interface{})
+	defaults["links"] = append(existing, link)
+}
+
+// addPanelLink 在 panel 的 links 中追加标题栏链接
+func addPanelLink(panel *Panel, spec LinkSpec) {
+	link := map[string]interface{}{
+		"title": spec.Title,
+		"url":   spec.URL,
+	}
+	if spec.TargetBlank {
+		link["targetBlank"] = true
+	}
+
+	existing, _ := panel.Extra["links"].([]interface{})
+	panel.setExtra("links", append(existing, link))
+}
+
+// exploreLink 由 panel 的第一个查询生成 Explore 内部链接，没有查询时返回 nil
+// 数据源取查询自身的 datasource，没有时使用 panel 的 datasource
+func exploreLink(panel *Panel) map[string]interface{} {
+	if len(panel.Targets) == 0 {
+		return nil
+	}
+	target := panel.Targets[0]
+
+	datasource := childObject(target.Extra, "datasource")
+	if datasource == nil {
+		datasource = childObject(panel.Extra, "datasource")
+	}
+
+	query := map[string]interface{}{"refId": target.RefID}
+	for key, value := range target.Extra {
+		query[key] = deepCopy(value)
+	}
+	if target.Expr != "" {
+		query["expr"] = target.Expr
+	}
+	if target.RawSQL != "" {
+		query["rawSql"] = target.RawSQL
+	}
+
+	return map[string]interface{}{
+		"datasourceUid": getString(datasource, "uid"),
+		"query":         query,
+	}
+}
+
+// childObject 返回 JSON 对象中的子对象，不存在或不是对象时返回 nil
+func childObject(obj map[string]interface{}, key string) map[string]interface{} {
+	child, _ := obj[key].(map[string]interface{})
+	return child
+}
@@ -0,0 +1,344 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"tunnel-monitor/internal/config"
+)
+
+// variableRefPattern 匹配查询中的变量引用：$var、${var}、${var:format} 和 [[var]]
+var variableRefPattern = regexp.MustCompile(`\$\{([A-Za-z_]\w*)(?:[:.][^}]*)?\}|\[\[([A-Za-z_]\w*)(?::\w+)?\]\]|\$([A-Za-z_]\w*)`)
+
+// builtinVariables Grafana 内置的变量，$__ 开头的变量也都是内置变量
+var builtinVariables = map[string]bool{
+	"interval":   true,
+	"timeFilter": true,
+	"timeFrom":   true,
+	"timeTo":     true,
+}
+
+// LintIssue 模板检查发现的问题
+type LintIssue struct {
+	File    string
+	Message string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.File, i.Message)
+}
+
+// linter 按一组功能开关检查模板，收集发现的问题
+type linter struct {
+	dir      string
+	files    *templateFiles
+	features map[string]bool
+	issues   []LintIssue
+}
+
+func (l *linter) report(file string, format string, args ...interface{}) {
+	l.issues = append(l.issues, LintIssue{File: file, Message: fmt.Sprintf(format, args...)})
+}
+
+// LintDashboards 检查所有模板和panels片段，发现问题时返回错误
+func LintDashboards() error {
+	dir := config.Global.Dashboards.Dir
+	if openTemplateFiles(dir).Embedded() {
+		fmt.Printf("🔍 %s 不存在，检查内置模板...\n", dir)
+	} else {
+		fmt.Printf("🔍 检查模板目录 %s...\n", dir)
+	}
+
+	issues, err := LintTemplates()
+	if err != nil {
+		return err
+	}
+
+	if len(issues) == 0 {
+		fmt.Println("✅ 模板检查通过")
+		return nil
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("❌ %s\n", issue)
+	}
+	return fmt.Errorf("模板检查发现 %d 个问题", len(issues))
+}
+
+// LintTemplates 检查 dashboards.dir 下的所有模板、panels片段和面板清单
+// 包括：JSON 格式、未定义的占位符、未定义的变量、重复的 panel ID 和没有 panel 的 row
+// 模板条件按 features 的所有组合分别渲染检查，只在部分组合中出现的问题会注明对应的功能开关
+func LintTemplates() ([]LintIssue, error) {
+	var issues []LintIssue
+	seen := make(map[string]bool)
+
+	for i, features := range featureCombinations(config.Global.Features) {
+		dir := config.Global.Dashboards.Dir
+		l := &linter{dir: dir, files: openTemplateFiles(dir), features: features}
+		if err := l.lint(); err != nil {
+			return nil, err
+		}
+
+		for _, issue := range l.issues {
+			key := issue.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			// 第一个组合为当前配置
+			if i > 0 {
+				issue.Message = fmt.Sprintf("%s [features: %s]", issue.Message, describeFeatures(features))
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// lint 检查所有 JSON 文件和面板清单
+func (l *linter) lint() error {
+	err := l.files.WalkDir(l.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			return nil
+		}
+		l.lintJSONFile(path)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("读取模板目录失败: %w", err)
+	}
+
+	l.lintManifests()
+	return nil
+}
+
+// featureCombinations 返回功能开关的所有组合，第一个为当前配置
+func featureCombinations(current map[string]bool) []map[string]bool {
+	var names []string
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	combinations := []map[string]bool{current}
+	for mask := 0; mask < 1<<len(names); mask++ {
+		features := make(map[string]bool)
+		same := true
+		for i, name := range names {
+			features[name] = mask&(1<<i) != 0
+			if features[name] != current[name] {
+				same = false
+			}
+		}
+		if !same {
+			combinations = append(combinations, features)
+		}
+	}
+	return combinations
+}
+
+// describeFeatures 按名称顺序输出功能开关，如 DNS=false, MySQL=true
+func describeFeatures(features map[string]bool) string {
+	var parts []string
+	for name, enabled := range features {
+		parts = append(parts, fmt.Sprintf("%s=%t", name, enabled))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// lintJSONFile 检查单个 JSON 文件，完整的 dashboard 模板同时检查其中的 panels
+func (l *linter) lintJSONFile(path string) {
+	data, err := l.files.ReadFile(path)
+	if err != nil {
+		l.report(path, "读取失败: %v", err)
+		return
+	}
+
+	data, err = renderTemplate(path, data, l.features)
+	if err != nil {
+		l.report(path, "%v", err)
+		return
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		if line, col, ok := jsonErrorPosition(data, err); ok {
+			path = fmt.Sprintf("%s:%d:%d", path, line, col)
+		}
+		l.report(path, "JSON 格式错误: %v", err)
+		return
+	}
+
+	l.lintPlaceholders(path, doc)
+
+	// 包含 templating 的是完整的 dashboard 模板，片段由面板清单组合时检查
+	if obj, ok := doc.(map[string]interface{}); !ok || obj["templating"] == nil {
+		return
+	}
+
+	dashboard, err := ParseDashboard(data)
+	if err != nil {
+		l.report(path, "%v", err)
+		return
+	}
+
+	// 基础模板的 panels 为空，由面板清单组合后再检查
+	if len(dashboard.Panels) > 0 {
+		l.lintPanels(path, dashboard.Variables(), dashboard.Panels)
+	}
+}
+
+// lintManifests 按面板清单组合每个面板，检查变量、panel ID 和 row
+func (l *linter) lintManifests() {
+	manifests, err := loadManifests(l.features)
+	if err != nil {
+		l.report(manifestsDir(), "%v", err)
+		return
+	}
+
+	for _, m := range manifests {
+		tm := NewTemplateManager(l.dir)
+		tm.Features = l.features
+
+		base, err := tm.loadBaseTemplate(m.Base)
+		if err != nil {
+			l.report(m.file, "加载基础模板失败: %v", err)
+			continue
+		}
+
+		panels, err := tm.assemblePanels(m)
+		if err != nil {
+			l.report(m.file, "%v", err)
+			continue
+		}
+
+		l.lintPanels(m.file, base.Variables(), panels)
+	}
+}
+
+// lintPlaceholders 检查模板中的占位符是否都能被替换（数据源UID或 template_vars 中的变量）
+func (l *linter) lintPlaceholders(path string, obj interface{}) {
+	known := config.Global.TemplatePlaceholders()
+	undefined := make(map[string]bool)
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for _, child := range val {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range val {
+				walk(child)
+			}
+		case string:
+			for _, placeholder := range placeholderPattern.FindAllString(val, -1) {
+				if _, ok := known[placeholder]; !ok {
+					undefined[placeholder] = true
+				}
+			}
+		}
+	}
+	walk(obj)
+
+	var names []string
+	for name := range undefined {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		l.report(path, "未定义的占位符 %s（请在 template_vars 中配置）", name)
+	}
+}
+
+// lintPanels 检查组合后的 panels：查询中的变量是否都已定义、panel ID 是否重复、row 是否为空
+func (l *linter) lintPanels(file string, variables []*TemplateVar, panels []*Panel) {
+	defined := make(map[string]bool)
+	for _, v := range variables {
+		defined[v.Name] = true
+	}
+
+	for _, v := range variables {
+		for _, name := range undefinedVariables(v.QueryString(), defined) {
+			l.report(file, "变量 %s 的查询引用了未定义的变量 $%s", v.Name, name)
+		}
+	}
+
+	for _, panel := range flattenPanels(panels) {
+		source := panel.extraString(sourceField)
+		if source == "" {
+			source = file
+		}
+		if v, ok := panel.Extra[orderField]; ok {
+			if _, ok := v.(float64); !ok {
+				l.report(source, "panel %q 的 %s 必须是数字", panel.Title, orderField)
+			}
+		}
+		for _, target := range panel.Targets {
+			for _, name := range undefinedVariables(target.Query(), defined) {
+				l.report(source, "panel %q 的查询 %s 使用了未定义的变量 $%s", panel.Title, target.RefID, name)
+			}
+		}
+	}
+
+	owners := make(map[int]string)
+	for _, ref := range collectPanelRefs(panels) {
+		id := ref.panel.ID
+		if id <= 0 {
+			continue
+		}
+		if owner, ok := owners[id]; ok {
+			l.report(file, "panel ID %d 重复: %s 与 %s", id, owner, ref.key)
+			continue
+		}
+		owners[id] = ref.key
+	}
+
+	for i, panel := range panels {
+		if panel.IsRow() && !rowHasPanels(panels, i) {
+			l.report(file, "row %q 没有任何 panel", panel.Title)
+		}
+	}
+}
+
+// rowHasPanels 折叠的 row 检查自身的 panels，展开的 row 检查其后是否紧跟非 row 的 panel
+func rowHasPanels(panels []*Panel, i int) bool {
+	row := panels[i]
+	if row.Collapsed {
+		return len(row.Panels) > 0
+	}
+
+	return i+1 < len(panels) && !panels[i+1].IsRow()
+}
+
+// undefinedVariables 返回查询中引用但未定义的变量，按出现顺序去重
+func undefinedVariables(query string, defined map[string]bool) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, match := range variableRefPattern.FindAllStringSubmatch(query, -1) {
+		name := match[1] + match[2] + match[3]
+		if strings.HasPrefix(name, "__") || builtinVariables[name] || defined[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	return names
+}
@@ -0,0 +1,165 @@
+package dashboard
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tunnel-monitor/dashboards"
+	"tunnel-monitor/internal/config"
+)
+
+// lintBase 基础模板，定义变量 pop
+const lintBase = `{
+	"title": "检查",
+	"templating": {"list": [{"name": "pop", "type": "query", "query": "label_values(up, instance)"}]},
+	"panels": []
+}`
+
+// lintPanel 返回使用 expr 查询的panels片段
+func lintPanel(id int, title, expr string) string {
+	return fmt.Sprintf(`{"id": %d, "type": "timeseries", "title": "%s", "targets": [{"refId": "A", "expr": "%s"}]}`, id, title, expr)
+}
+
+// writeTemplateDir 在临时模板目录中写入文件
+// 同时创建内置模板中的所有目录，缺少的目录不会使用内置模板，只检查写入的文件
+func writeTemplateDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	err := fs.WalkDir(dashboards.FS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		return os.MkdirAll(filepath.Join(dir, filepath.FromSlash(path)), 0755)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLintTemplates(t *testing.T) {
+	manifest := "title: 检查\nbase: base.json\nrows:\n  - title: 客户端\n    panels:\n      - panels/x/*.json\n"
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string // 每个问题应包含的内容（路径相对于模板目录），顺序与输出一致
+	}{
+		{
+			name: "没有问题",
+			files: map[string]string{
+				"panels/x/a.json": lintPanel(1, "a", `up{instance=~\"$pop\"}`),
+			},
+		},
+		{
+			name: "未定义的占位符",
+			files: map[string]string{
+				"panels/x/a.json": `{"id": 1, "type": "stat", "title": "a", "datasource": {"uid": "{{PROMETHEUS_UID}}"}, "description": "{{UNKNOWN}}"}`,
+			},
+			want: []string{"panels/x/a.json: 未定义的占位符 {{UNKNOWN}}（请在 template_vars 中配置）"},
+		},
+		{
+			name: "panel 查询使用了未定义的变量",
+			files: map[string]string{
+				"panels/x/a.json": lintPanel(1, "a", `up{instance=~\"$pop\", region=~\"${region:regex}\"}`),
+			},
+			want: []string{`panels/x/a.json: panel "a" 的查询 A 使用了未定义的变量 $region`},
+		},
+		{
+			name: "变量查询引用了未定义的变量",
+			files: map[string]string{
+				"base.json":       strings.Replace(lintBase, "label_values(up, instance)", "label_values(up{job=\\\"$job\\\"}, instance)", 1),
+				"panels/x/a.json": lintPanel(1, "a", "up"),
+			},
+			want: []string{"manifests/check.yaml: 变量 pop 的查询引用了未定义的变量 $job"},
+		},
+		{
+			name: "内置变量不报错",
+			files: map[string]string{
+				"panels/x/a.json": lintPanel(1, "a", `rate(up[$__rate_interval]) or rate(up[$interval])`),
+			},
+		},
+		{
+			name: "重复的 panel ID",
+			files: map[string]string{
+				"panels/x/a.json": lintPanel(1, "a", "up"),
+				"panels/x/b.json": lintPanel(1, "b", "up"),
+			},
+			want: []string{"manifests/check.yaml: panel ID 1 重复"},
+		},
+		{
+			name: "没有 panel 的 row",
+			files: map[string]string{
+				"manifests/check.yaml": manifest + "  - title: 空\n    panels:\n      - panels/empty/*.json\n",
+				"panels/x/a.json":      lintPanel(1, "a", "up"),
+			},
+			want: []string{`manifests/check.yaml: row "空" 没有任何 panel`},
+		},
+		{
+			name: "只在部分功能开关组合中出现的问题",
+			files: map[string]string{
+				"panels/x/a.json": lintPanel(1, "a", `up{% if .Features.DNS %}{domain=~\"$domain\"}{% end %}`),
+			},
+			want: []string{`panels/x/a.json: panel "a" 的查询 A 使用了未定义的变量 $domain [features: DNS=true]`},
+		},
+		{
+			name: "JSON 格式错误",
+			files: map[string]string{
+				"panels/x/a.json": "{\n  \"id\": 1,\n  \"title\": \"a\"\n  \"type\": \"stat\"\n}",
+			},
+			want: []string{
+				"panels/x/a.json:4:",
+				"manifests/check.yaml: 加载 row 客户端 的panels失败",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{"base.json": lintBase, "manifests/check.yaml": manifest}
+			for name, content := range tt.files {
+				files[name] = content
+			}
+			dir := writeTemplateDir(t, files)
+
+			previous := config.Global
+			t.Cleanup(func() { config.Global = previous })
+			config.Global = &config.Config{}
+			config.Global.Dashboards.Dir = dir
+			config.Global.Features = map[string]bool{"DNS": false}
+			config.Global.Grafana.PrometheusUID = "prom"
+
+			issues, err := LintTemplates()
+			if err != nil {
+				t.Fatalf("LintTemplates: %v", err)
+			}
+
+			var got []string
+			for _, issue := range issues {
+				got = append(got, strings.TrimPrefix(issue.String(), dir+string(filepath.Separator)))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("发现 %d 个问题，期望 %d 个:\n%s", len(got), len(tt.want), strings.Join(got, "\n"))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("第 %d 个问题 = %s，期望包含 %s", i+1, got[i], want)
+				}
+			}
+		})
+	}
+}
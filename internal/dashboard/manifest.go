@@ -0,0 +1,314 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"tunnel-monitor/internal/config"
+)
+
+// manifestsDirName 面板清单所在的子目录（相对于 dashboards.dir）
+const manifestsDirName = "manifests"
+
+// Manifest 声明式的面板定义
+// 描述基础模板、UID、标题，以及每个 row 的折叠状态和包含的panels片段
+// 所有路径都相对于 dashboards.dir，panels 支持 glob（如 panels/client/*.json）
+type Manifest struct {
+	Name   string          `yaml:"name,omitempty"` // 面板名称，默认为清单文件名
+	UID    string          `yaml:"uid,omitempty"`
+	Title  string          `yaml:"title"`
+	Base   string          `yaml:"base"`             // 基础模板
+	Folder string          `yaml:"folder,omitempty"` // Grafana 文件夹，配置中的 dashboards.folders 优先
+	Panels []string        `yaml:"panels,omitempty"` // 不属于任何 row 的panels，位于第一个 row 之前
+	Rows   []ManifestRow   `yaml:"rows,omitempty"`
+	Tips   []string        `yaml:"tips,omitempty"`    // 创建成功后输出的提示
+	Links  []ManifestLinks `yaml:"links,omitempty"`   // 按panels片段配置的数据链接和标题栏链接
+	PerPOP *PerPOP         `yaml:"per_pop,omitempty"` // 按 POP 生成面板，由 create-pop-details 创建；其他命令创建不锁定变量的下钻面板
+
+	file string
+}
+
+// ManifestRow 清单中的一个 row
+type ManifestRow struct {
+	Title     string   `yaml:"title"`
+	ID        int      `yaml:"id,omitempty"`
+	Collapsed bool     `yaml:"collapsed,omitempty"`
+	Panels    []string `yaml:"panels,omitempty"`
+}
+
+// PerPOP 按 POP 生成面板的配置
+type PerPOP struct {
+	Variables []string `yaml:"variables"` // 锁定为当前 POP 的变量，值为 POP 的 exported_instance
+}
+
+// manifestsDir 返回面板清单目录
+func manifestsDir() string {
+	return filepath.Join(config.Global.Dashboards.Dir, manifestsDirName)
+}
+
+// LoadManifest 按名称加载面板清单（dashboards/manifests/<name>.yaml）
+func LoadManifest(name string) (*Manifest, error) {
+	manifests, err := LoadManifests()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range manifests {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+
+	// 清单存在但被模板条件整个排除
+	files := openTemplateFiles(config.Global.Dashboards.Dir)
+	if matches, _ := files.Glob(filepath.Join(manifestsDir(), name+".y*ml")); len(matches) > 0 {
+		return nil, fmt.Errorf("面板清单 %s 在当前功能开关（%s）下没有内容", name, describeFeatures(config.Global.Features))
+	}
+
+	return nil, fmt.Errorf("未找到面板清单 %s（%s）", name, manifestsDir())
+}
+
+// LoadManifests 加载清单目录中的所有面板清单，按文件名排序
+func LoadManifests() ([]*Manifest, error) {
+	return loadManifests(config.Global.Features)
+}
+
+// loadManifests 按指定的功能开关渲染并加载所有面板清单
+func loadManifests(features map[string]bool) ([]*Manifest, error) {
+	dir := manifestsDir()
+	files := openTemplateFiles(config.Global.Dashboards.Dir)
+
+	entries, err := files.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取面板清单目录失败: %w", err)
+	}
+
+	var manifests []*Manifest
+	names := make(map[string]string)
+	uids := make(map[string]string)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+			continue
+		}
+
+		m, err := loadManifestFile(files, filepath.Join(dir, name), features)
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			continue
+		}
+
+		if other, ok := names[m.Name]; ok {
+			return nil, fmt.Errorf("面板清单名称 %s 重复（%s 和 %s）", m.Name, other, m.file)
+		}
+		names[m.Name] = m.file
+
+		// UID 相同的面板在创建和渲染时会互相覆盖
+		uid := dashboardUID(m)
+		if other, ok := uids[uid]; ok {
+			return nil, fmt.Errorf("面板UID %s 重复（%s 和 %s）", uid, other, m.file)
+		}
+		uids[uid] = m.file
+
+		manifests = append(manifests, m)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].file < manifests[j].file
+	})
+
+	return manifests, nil
+}
+
+// loadManifestFile 加载并校验单个清单文件，清单中同样可以使用模板条件
+// 模板条件排除了全部内容时返回 nil，该面板在当前功能开关下不创建
+func loadManifestFile(files *templateFiles, path string, features map[string]bool) (*Manifest, error) {
+	data, err := files.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取面板清单 %s 失败: %w", path, err)
+	}
+
+	data, err = renderTemplate(path, data, features)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	m := &Manifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("解析面板清单 %s 失败: %w", path, err)
+	}
+	m.file = path
+
+	if m.Name == "" {
+		m.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if m.Base == "" {
+		return nil, fmt.Errorf("面板清单 %s 缺少 base", path)
+	}
+	if m.PerPOP != nil && len(m.PerPOP.Variables) == 0 {
+		return nil, fmt.Errorf("面板清单 %s 的 per_pop 缺少 variables", path)
+	}
+	for i, links := range m.Links {
+		if err := links.validate(); err != nil {
+			return nil, fmt.Errorf("面板清单 %s 的第 %d 个 links: %w", path, i+1, err)
+		}
+	}
+	for i, row := range m.Rows {
+		if row.Title == "" {
+			return nil, fmt.Errorf("面板清单 %s 的第 %d 个 row 缺少 title", path, i+1)
+		}
+	}
+
+	return m, nil
+}
+
+// dashboardUID 返回面板UID，配置中设置了 business_uid、server_uid、unified_uid、database_uid 时优先于清单
+func dashboardUID(m *Manifest) string {
+	cfg := config.Global
+
+	overrides := map[string]string{
+		"business": cfg.Dashboards.BusinessUID,
+		"server":   cfg.Dashboards.ServerUID,
+		"client":   cfg.Dashboards.UnifiedUID,
+		"database": cfg.Dashboards.DatabaseUID,
+	}
+	if uid := overrides[m.Name]; uid != "" {
+		return uid
+	}
+
+	if m.UID != "" {
+		return m.UID
+	}
+	return m.Name
+}
+
+// dashboardFolder 返回面板所在的 Grafana 文件夹
+// 优先级：dashboards.folders[name] > 清单中的 folder > dashboards.folder
+func dashboardFolder(m *Manifest) string {
+	cfg := config.Global
+	if folder, ok := cfg.Dashboards.Folders[m.Name]; ok {
+		return folder
+	}
+	if m.Folder != "" {
+		return m.Folder
+	}
+	return cfg.Dashboards.Folder
+}
+
+// panelDirs 返回清单引用的panels片段所在目录（相对于 dashboards.dir），按首次出现的顺序
+func (m *Manifest) panelDirs() []string {
+	var dirs []string
+	seen := make(map[string]bool)
+
+	patterns := append([]string{}, m.Panels...)
+	for _, row := range m.Rows {
+		patterns = append(patterns, row.Panels...)
+	}
+
+	for _, pattern := range patterns {
+		dir := filepath.Dir(pattern)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+// BuildDashboard 根据清单组合面板：加载基础模板，按 row 加载panels片段
+// 所有 gridPos 由 layoutPanels 根据片段声明的宽高重新计算，重复的 panel ID 由 assignPanelIDs 重新分配
+func (tm *TemplateManager) BuildDashboard(m *Manifest) (*Dashboard, error) {
+	dashboard, err := tm.loadBaseTemplate(m.Base)
+	if err != nil {
+		return nil, fmt.Errorf("加载基础模板失败: %w", err)
+	}
+
+	panels, err := tm.assemblePanels(m)
+	if err != nil {
+		return nil, err
+	}
+
+	layoutPanels(panels)
+	assignPanelIDs(panels)
+
+	if panels == nil {
+		panels = []*Panel{}
+	}
+	dashboard.Panels = panels
+	dashboard.Title = m.Title
+	dashboard.UID = dashboardUID(m)
+
+	return dashboard, nil
+}
+
+// assemblePanels 按清单顺序加载panels片段并插入 row，再按片段中的 x-row、x-order 调整，最后添加清单中配置的链接
+// 展开的 row 后面紧跟其 panels，折叠的 row 把 panels 放在自身的 panels 字段中
+func (tm *TemplateManager) assemblePanels(m *Manifest) ([]*Panel, error) {
+	panels, err := tm.loadPanelPatterns(m.Panels)
+	if err != nil {
+		return nil, err
+	}
+	groups := []*panelGroup{{panels: panels}}
+
+	for _, row := range m.Rows {
+		children, err := tm.loadPanelPatterns(row.Panels)
+		if err != nil {
+			return nil, fmt.Errorf("加载 row %s 的panels失败: %w", row.Title, err)
+		}
+
+		groups = append(groups, &panelGroup{
+			row:    newRowPanel(row.Title, row.ID, row.Collapsed),
+			panels: children,
+		})
+	}
+
+	panels = arrangePanels(groups)
+	if err := tm.applyLinks(m, panels); err != nil {
+		return nil, err
+	}
+	return panels, nil
+}
+
+// loadPanelPatterns 按顺序加载匹配 glob 的panels片段，同一文件只加载一次
+func (tm *TemplateManager) loadPanelPatterns(patterns []string) ([]*Panel, error) {
+	var panels []*Panel
+	seen := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		files, err := tm.files.Glob(tm.resolvePath(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("panels路径 %s 格式错误: %w", pattern, err)
+		}
+
+		for _, file := range files {
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+
+			filePanels, err := tm.loadPanelFromFile(file)
+			if err != nil {
+				if err := tm.skipPanelFile(err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			// 模板条件排除了整个片段时 filePanels 为空
+			panels = append(panels, tagSource(filePanels, file)...)
+		}
+	}
+
+	return panels, nil
+}
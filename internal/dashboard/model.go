@@ -0,0 +1,596 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Dashboard Grafana dashboard JSON 模型
+// 只对组合、检查和导入时用到的字段建模，其他字段保存在 Extra 中，序列化时原样输出
+type Dashboard struct {
+	UID        string
+	Title      string
+	Panels     []*Panel
+	Templating *Templating // 没有 templating 字段时为 nil
+	Extra      map[string]interface{}
+
+	present map[string]bool // 原 JSON 中存在的字段，值为零值时也会输出
+}
+
+// Templating dashboard 的变量定义
+type Templating struct {
+	List  []*TemplateVar
+	Extra map[string]interface{}
+}
+
+// TemplateVar 模板变量，Query 可能是字符串或包含 query、rawSql 字段的对象
+type TemplateVar struct {
+	Name  string
+	Type  string
+	Label string
+	Query interface{}
+	Extra map[string]interface{}
+
+	present map[string]bool
+}
+
+// Panel dashboard 中的面板，row 也是 Panel，折叠 row 的子面板保存在 Panels 中
+// 片段扩展字段（x-source、x-order、x-row）保存在 Extra 中
+type Panel struct {
+	ID        int
+	Type      string
+	Title     string
+	GridPos   *GridPos
+	Targets   []*Target
+	Panels    []*Panel
+	Collapsed bool
+	Extra     map[string]interface{}
+
+	present map[string]bool
+}
+
+// Target 面板的查询
+type Target struct {
+	RefID  string
+	Expr   string // PromQL
+	RawSQL string // SQL
+	Extra  map[string]interface{}
+
+	present map[string]bool
+}
+
+// GridPos 面板在 24 列网格中的位置和大小
+type GridPos struct {
+	X, Y, W, H int
+	Extra      map[string]interface{}
+}
+
+// ParseDashboard 解析 dashboard JSON，字段类型不符时返回带字段路径的错误
+func ParseDashboard(data []byte) (*Dashboard, error) {
+	d := &Dashboard{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// IsRow 判断面板是否为 row
+func (p *Panel) IsRow() bool {
+	return p.Type == "row"
+}
+
+// extraString 返回 Extra 中的字符串字段，用于读取片段扩展字段
+func (p *Panel) extraString(key string) string {
+	return getString(p.Extra, key)
+}
+
+// setExtra 设置 Extra 中的字段
+func (p *Panel) setExtra(key string, value interface{}) {
+	if p.Extra == nil {
+		p.Extra = make(map[string]interface{})
+	}
+	p.Extra[key] = value
+}
+
+// Query 返回查询语句（PromQL 或 SQL）
+func (t *Target) Query() string {
+	if t.Expr != "" {
+		return t.Expr
+	}
+	return t.RawSQL
+}
+
+// QueryString 返回变量的查询语句，query 为对象时取其中的 query 或 rawSql
+func (v *TemplateVar) QueryString() string {
+	if q, ok := v.Query.(map[string]interface{}); ok {
+		if query := getString(q, "query"); query != "" {
+			return query
+		}
+		return getString(q, "rawSql")
+	}
+	s, _ := v.Query.(string)
+	return s
+}
+
+// Variables 返回变量列表，没有 templating 时返回 nil
+func (d *Dashboard) Variables() []*TemplateVar {
+	if d.Templating == nil {
+		return nil
+	}
+	return d.Templating.List
+}
+
+// AllPanels 返回所有面板，折叠 row 中的子面板紧跟在 row 之后
+func (d *Dashboard) AllPanels() []*Panel {
+	return flattenPanels(d.Panels)
+}
+
+// flattenPanels 返回所有面板，包括 row 和折叠 row 中的子面板
+func flattenPanels(panels []*Panel) []*Panel {
+	var result []*Panel
+	for _, p := range panels {
+		result = append(result, p)
+		if p.IsRow() {
+			result = append(result, p.Panels...)
+		}
+	}
+	return result
+}
+
+// extras 返回模型中所有的 Extra，数据源引用等未建模的字段都在其中
+func (d *Dashboard) extras() []map[string]interface{} {
+	result := []map[string]interface{}{d.Extra}
+	if d.Templating != nil {
+		result = append(result, d.Templating.Extra)
+		for _, v := range d.Templating.List {
+			result = append(result, v.Extra)
+		}
+	}
+
+	var walk func(panels []*Panel)
+	walk = func(panels []*Panel) {
+		for _, p := range panels {
+			result = append(result, p.Extra)
+			for _, t := range p.Targets {
+				result = append(result, t.Extra)
+			}
+			walk(p.Panels)
+		}
+	}
+	walk(d.Panels)
+
+	return result
+}
+
+// transform 将 dashboard 转换为通用 JSON 值交给 fn 处理，再解码回模型
+// 用于覆盖配置、占位符替换等按 JSON 结构整体处理的步骤，处理结果的字段类型不符时返回错误
+func (d *Dashboard) transform(fn func(doc map[string]interface{}) (interface{}, error)) error {
+	doc, err := toJSONValue(d)
+	if err != nil {
+		return err
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("dashboard 不是 JSON 对象")
+	}
+
+	result, err := fn(obj)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	updated, err := ParseDashboard(data)
+	if err != nil {
+		return err
+	}
+	*d = *updated
+	return nil
+}
+
+// toJSONValue 将值转换为通用 JSON 值（map、切片、float64 等）
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d *Dashboard) UnmarshalJSON(data []byte) error {
+	f, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+
+	*d = Dashboard{present: f.present()}
+	f.take("uid", &d.UID)
+	f.take("title", &d.Title)
+	d.Panels = f.takePanels("panels")
+	if f.has("templating") {
+		d.Templating = &Templating{}
+		f.take("templating", d.Templating)
+	}
+	d.Extra = f.rest()
+
+	return f.err
+}
+
+func (d *Dashboard) MarshalJSON() ([]byte, error) {
+	obj := newJSONObject(d.Extra, d.present)
+	obj.set("uid", d.UID)
+	obj.set("title", d.Title)
+	obj.setList("panels", d.Panels)
+	if d.Templating != nil {
+		obj.fields["templating"] = d.Templating
+	}
+	return obj.marshal()
+}
+
+func (t *Templating) UnmarshalJSON(data []byte) error {
+	f, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+
+	*t = Templating{}
+	if raws := f.takeList("list"); raws != nil {
+		t.List = make([]*TemplateVar, len(raws))
+		for i, raw := range raws {
+			t.List[i] = &TemplateVar{}
+			f.decodeItem("list", i, raw, t.List[i])
+		}
+	}
+	t.Extra = f.rest()
+
+	return f.err
+}
+
+func (t *Templating) MarshalJSON() ([]byte, error) {
+	obj := newJSONObject(t.Extra, nil)
+	obj.setList("list", t.List)
+	return obj.marshal()
+}
+
+func (v *TemplateVar) UnmarshalJSON(data []byte) error {
+	f, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+
+	*v = TemplateVar{present: f.present()}
+	f.take("name", &v.Name)
+	f.take("type", &v.Type)
+	f.take("label", &v.Label)
+	f.take("query", &v.Query)
+	v.Extra = f.rest()
+
+	return f.err
+}
+
+func (v *TemplateVar) MarshalJSON() ([]byte, error) {
+	obj := newJSONObject(v.Extra, v.present)
+	obj.set("name", v.Name)
+	obj.set("type", v.Type)
+	obj.set("label", v.Label)
+	if v.Query != nil || v.present["query"] {
+		obj.fields["query"] = v.Query
+	}
+	return obj.marshal()
+}
+
+func (p *Panel) UnmarshalJSON(data []byte) error {
+	f, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+
+	*p = Panel{present: f.present()}
+	f.take("id", &p.ID)
+	f.take("type", &p.Type)
+	f.take("title", &p.Title)
+	if f.has("gridPos") {
+		p.GridPos = &GridPos{}
+		f.take("gridPos", p.GridPos)
+	}
+	if raws := f.takeList("targets"); raws != nil {
+		p.Targets = make([]*Target, len(raws))
+		for i, raw := range raws {
+			p.Targets[i] = &Target{}
+			f.decodeItem("targets", i, raw, p.Targets[i])
+		}
+	}
+	p.Panels = f.takePanels("panels")
+	f.take("collapsed", &p.Collapsed)
+	p.Extra = f.rest()
+
+	return f.err
+}
+
+func (p *Panel) MarshalJSON() ([]byte, error) {
+	obj := newJSONObject(p.Extra, p.present)
+	obj.set("id", p.ID)
+	obj.set("type", p.Type)
+	obj.set("title", p.Title)
+	if p.GridPos != nil {
+		obj.fields["gridPos"] = p.GridPos
+	}
+	obj.setList("targets", p.Targets)
+	obj.setList("panels", p.Panels)
+	obj.set("collapsed", p.Collapsed)
+	return obj.marshal()
+}
+
+func (t *Target) UnmarshalJSON(data []byte) error {
+	f, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+
+	*t = Target{present: f.present()}
+	f.take("refId", &t.RefID)
+	f.take("expr", &t.Expr)
+	f.take("rawSql", &t.RawSQL)
+	t.Extra = f.rest()
+
+	return f.err
+}
+
+func (t *Target) MarshalJSON() ([]byte, error) {
+	obj := newJSONObject(t.Extra, t.present)
+	obj.set("refId", t.RefID)
+	obj.set("expr", t.Expr)
+	obj.set("rawSql", t.RawSQL)
+	return obj.marshal()
+}
+
+func (g *GridPos) UnmarshalJSON(data []byte) error {
+	f, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+
+	*g = GridPos{}
+	f.take("x", &g.X)
+	f.take("y", &g.Y)
+	f.take("w", &g.W)
+	f.take("h", &g.H)
+	g.Extra = f.rest()
+
+	return f.err
+}
+
+func (g *GridPos) MarshalJSON() ([]byte, error) {
+	obj := newJSONObject(g.Extra, nil)
+	obj.fields["x"] = g.X
+	obj.fields["y"] = g.Y
+	obj.fields["w"] = g.W
+	obj.fields["h"] = g.H
+	return obj.marshal()
+}
+
+// fieldError 模型字段解析错误，path 为出错字段的位置，如 panels[3].gridPos.w
+type fieldError struct {
+	path string
+	msg  string
+}
+
+func (e *fieldError) Error() string {
+	if e.path == "" {
+		return e.msg
+	}
+	return fmt.Sprintf("%s: %s", e.path, e.msg)
+}
+
+// wrapFieldError 在错误的字段路径前加上上级字段
+func wrapFieldError(field string, err error) error {
+	var fe *fieldError
+	if !errors.As(err, &fe) {
+		return &fieldError{path: field, msg: describeDecodeError(err)}
+	}
+
+	path := fe.path
+	if path != "" && !strings.HasPrefix(path, "[") {
+		path = "." + path
+	}
+	return &fieldError{path: field + path, msg: fe.msg}
+}
+
+// jsonValueNames encoding/json 类型错误中的 JSON 值类型
+var jsonValueNames = map[string]string{
+	"string": "字符串",
+	"number": "数字",
+	"bool":   "布尔值",
+	"array":  "数组",
+	"object": "对象",
+}
+
+// describeDecodeError 将 encoding/json 的类型错误转换为可读的说明，如 应为整数，实际为字符串
+func describeDecodeError(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		return err.Error()
+	}
+
+	value := typeErr.Value
+	kind, literal, _ := strings.Cut(value, " ")
+	if name, ok := jsonValueNames[kind]; ok {
+		value = name
+		if literal != "" {
+			value += " " + literal
+		}
+	}
+	return fmt.Sprintf("应为%s，实际为%s", jsonTypeName(typeErr.Type), value)
+}
+
+// jsonTypeName 返回 Go 类型对应的 JSON 类型名称
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "字符串"
+	case reflect.Bool:
+		return "布尔值"
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return "整数"
+	case reflect.Slice:
+		return "数组"
+	}
+	return "对象"
+}
+
+// objectDecoder 逐个取出 JSON 对象中已建模的字段，剩余的字段作为 Extra
+// 记录遇到的第一个错误，之后的字段不再解析
+type objectDecoder struct {
+	fields map[string]json.RawMessage
+	keys   map[string]bool
+	err    error
+}
+
+// decodeObject 解析 JSON 对象，不是对象时返回错误
+func decodeObject(data []byte) (*objectDecoder, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, &fieldError{msg: describeDecodeError(err)}
+	}
+	if fields == nil {
+		return nil, &fieldError{msg: "应为对象，实际为 null"}
+	}
+
+	keys := make(map[string]bool, len(fields))
+	for k := range fields {
+		keys[k] = true
+	}
+	return &objectDecoder{fields: fields, keys: keys}, nil
+}
+
+// present 返回对象中的所有字段名
+func (f *objectDecoder) present() map[string]bool {
+	return f.keys
+}
+
+func (f *objectDecoder) has(key string) bool {
+	_, ok := f.fields[key]
+	return ok
+}
+
+// take 取出字段并解码到 v，字段不存在时保持 v 不变
+func (f *objectDecoder) take(key string, v interface{}) {
+	raw, ok := f.fields[key]
+	if !ok {
+		return
+	}
+	delete(f.fields, key)
+
+	if f.err != nil {
+		return
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		f.err = wrapFieldError(key, err)
+	}
+}
+
+// takeList 取出数组字段，字段不存在或为 null 时返回 nil，空数组返回长度为 0 的切片
+func (f *objectDecoder) takeList(key string) []json.RawMessage {
+	var raws []json.RawMessage
+	f.take(key, &raws)
+	return raws
+}
+
+// decodeItem 解码数组中的一项，错误路径包含下标
+func (f *objectDecoder) decodeItem(key string, i int, raw json.RawMessage, v interface{}) {
+	if f.err != nil {
+		return
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		f.err = wrapFieldError(key, wrapFieldError(fmt.Sprintf("[%d]", i), err))
+	}
+}
+
+// takePanels 取出 panel 数组字段
+func (f *objectDecoder) takePanels(key string) []*Panel {
+	raws := f.takeList(key)
+	if raws == nil {
+		return nil
+	}
+
+	panels := make([]*Panel, len(raws))
+	for i, raw := range raws {
+		panels[i] = &Panel{}
+		f.decodeItem(key, i, raw, panels[i])
+	}
+	return panels
+}
+
+// rest 返回未被取出的字段
+func (f *objectDecoder) rest() map[string]interface{} {
+	if f.err != nil || len(f.fields) == 0 {
+		return nil
+	}
+
+	extra := make(map[string]interface{}, len(f.fields))
+	for key, raw := range f.fields {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			f.err = wrapFieldError(key, err)
+			return nil
+		}
+		extra[key] = v
+	}
+	return extra
+}
+
+// jsonObject 序列化模型时组合已建模的字段和 Extra
+type jsonObject struct {
+	fields  map[string]interface{}
+	present map[string]bool
+}
+
+func newJSONObject(extra map[string]interface{}, present map[string]bool) *jsonObject {
+	fields := make(map[string]interface{}, len(extra)+8)
+	for k, v := range extra {
+		fields[k] = v
+	}
+	return &jsonObject{fields: fields, present: present}
+}
+
+// set 输出非零值的字段，原 JSON 中存在的字段即使为零值也会输出
+func (o *jsonObject) set(key string, value interface{}) {
+	if !reflect.ValueOf(value).IsZero() || o.present[key] {
+		o.fields[key] = value
+	}
+}
+
+// setList 输出非 nil 的数组字段，空数组输出为 []
+func (o *jsonObject) setList(key string, list interface{}) {
+	if !reflect.ValueOf(list).IsNil() {
+		o.fields[key] = list
+	}
+}
+
+// marshal 按字段名排序输出，不转义 HTML 字符，与 writeJSONFile 的格式一致
+func (o *jsonObject) marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(o.fields); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,74 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testdata 中从 Grafana 导出的面板经过模型解析再序列化后内容不变，未建模的字段保存在 Extra 中
+func TestDashboardRoundTrip(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*-export.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("testdata 中没有导出的面板")
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dashboard, err := ParseDashboard(data)
+			if err != nil {
+				t.Fatalf("ParseDashboard: %v", err)
+			}
+			if len(dashboard.Panels) == 0 || dashboard.Templating == nil {
+				t.Fatalf("没有解析出 panels 或 templating")
+			}
+
+			out, err := json.Marshal(dashboard)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+
+			var want, got interface{}
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("序列化结果与原文件不同:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestPanelRoundTripKeepsZeroValues(t *testing.T) {
+	// 原文件中显式写出的零值（如 id 为 0、collapsed 为 false）序列化后保留，未出现的字段不会添加
+	data := `{"id":0,"type":"row","title":"","collapsed":false,"panels":[],"x-order":2,"fieldConfig":{"defaults":{}}}`
+
+	var panel Panel
+	if err := json.Unmarshal([]byte(data), &panel); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(&panel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := parseJSON(t, string(out)), parseJSON(t, data); !reflect.DeepEqual(got, want) {
+		t.Errorf("序列化结果 %s，期望 %s", out, data)
+	}
+	if _, ok := panel.Extra["x-order"]; !ok {
+		t.Error("未建模的字段没有保存在 Extra 中")
+	}
+}
@@ -0,0 +1,326 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// overlaysDirName 环境覆盖配置所在的子目录（相对于 dashboards.dir）
+// 目录结构为 overlays/<环境>/<面板清单名称>.json
+const overlaysDirName = "overlays"
+
+// ApplyOverlay 将 tm.Env 环境的覆盖配置应用到清单 name 组合后的面板
+// 覆盖文件为 JSON 对象时按 RFC 7386 merge patch 合并，为数组时按 RFC 6902 JSON Patch 执行
+// 未指定环境或环境目录中没有该面板的覆盖文件时面板保持不变；环境目录不存在时返回错误
+func (tm *TemplateManager) ApplyOverlay(dashboard *Dashboard, name string) error {
+	if tm.Env == "" {
+		return nil
+	}
+
+	envDir := tm.resolvePath(filepath.Join(overlaysDirName, tm.Env))
+	if _, err := tm.files.ReadDir(envDir); err != nil {
+		return fmt.Errorf("未找到环境 %s 的覆盖目录 %s", tm.Env, envDir)
+	}
+
+	path := filepath.Join(envDir, name+".json")
+	data, err := tm.readFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取覆盖文件失败: %w", err)
+	}
+
+	data, err = renderTemplate(path, data, tm.Features)
+	if err != nil {
+		return err
+	}
+
+	var patch interface{}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return fmt.Errorf("解析覆盖文件失败: %w", jsonFileError(path, data, err))
+	}
+
+	err = dashboard.transform(func(doc map[string]interface{}) (interface{}, error) {
+		switch p := patch.(type) {
+		case map[string]interface{}:
+			return mergePatch(doc, p), nil
+		case []interface{}:
+			return applyJSONPatch(doc, p)
+		}
+		return nil, fmt.Errorf("覆盖文件必须是 JSON 对象（merge patch）或数组（JSON Patch）")
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	// 覆盖配置可能增删 panel，重新计算布局和 panel ID
+	layoutPanels(dashboard.Panels)
+	assignPanelIDs(dashboard.Panels)
+
+	return nil
+}
+
+// mergePatch 按 RFC 7386 合并：对象逐字段递归合并，null 删除字段，其他值（包括数组）整体替换
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
+
+// applyJSONPatch 按 RFC 6902 依次执行 add、remove、replace、move、copy、test 操作
+func applyJSONPatch(doc interface{}, ops []interface{}) (interface{}, error) {
+	for i, o := range ops {
+		op, ok := o.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("第 %d 个操作不是 JSON 对象", i+1)
+		}
+
+		path, ok := op["path"].(string)
+		if !ok {
+			return nil, fmt.Errorf("第 %d 个操作缺少 path", i+1)
+		}
+
+		var err error
+		switch name := getString(op, "op"); name {
+		case "add":
+			doc, err = pointerAdd(doc, path, op["value"])
+		case "remove":
+			doc, _, err = pointerRemove(doc, path)
+		case "replace":
+			if _, err = pointerGet(doc, path); err == nil {
+				doc, _, err = pointerRemove(doc, path)
+			}
+			if err == nil {
+				doc, err = pointerAdd(doc, path, op["value"])
+			}
+		case "move", "copy":
+			from, ok := op["from"].(string)
+			if !ok {
+				return nil, fmt.Errorf("第 %d 个操作（%s）缺少 from", i+1, name)
+			}
+			var value interface{}
+			if name == "move" {
+				doc, value, err = pointerRemove(doc, from)
+			} else {
+				value, err = pointerGet(doc, from)
+				value = deepCopy(value)
+			}
+			if err == nil {
+				doc, err = pointerAdd(doc, path, value)
+			}
+		case "test":
+			var value interface{}
+			if value, err = pointerGet(doc, path); err == nil && !reflect.DeepEqual(value, op["value"]) {
+				err = fmt.Errorf("%s 的值与预期不符", path)
+			}
+		default:
+			return nil, fmt.Errorf("第 %d 个操作的 op %q 不支持", i+1, name)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个操作（%s %s）失败: %w", i+1, getString(op, "op"), path, err)
+		}
+	}
+
+	return doc, nil
+}
+
+// parsePointer 解析 RFC 6901 JSON Pointer，返回各级引用
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON Pointer %q 必须以 / 开头", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex 解析数组下标，allowEnd 为 true 时允许等于长度（追加位置）和 "-"
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return length, nil
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("数组下标 %q 无效", token)
+	}
+	if index > length || (!allowEnd && index == length) {
+		return 0, fmt.Errorf("数组下标 %d 超出范围（长度 %d）", index, length)
+	}
+	return index, nil
+}
+
+// pointerGet 返回 JSON Pointer 指向的值
+func pointerGet(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("字段 %q 不存在", token)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("%q 的上级不是对象或数组", token)
+		}
+	}
+	return current, nil
+}
+
+// pointerAdd 在 JSON Pointer 位置添加值：对象中设置字段，数组中插入元素，空路径替换整个文档
+func pointerAdd(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return updateParent(doc, tokens, func(parent interface{}, last string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[last] = value
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(last, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		return nil, fmt.Errorf("%q 的上级不是对象或数组", last)
+	})
+}
+
+// pointerRemove 删除 JSON Pointer 位置的值并返回被删除的值
+func pointerRemove(doc interface{}, pointer string) (interface{}, interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("不能删除整个文档")
+	}
+
+	var removed interface{}
+	doc, err = updateParent(doc, tokens, func(parent interface{}, last string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			value, ok := node[last]
+			if !ok {
+				return nil, fmt.Errorf("字段 %q 不存在", last)
+			}
+			removed = value
+			delete(node, last)
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(last, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[index]
+			return append(node[:index], node[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("%q 的上级不是对象或数组", last)
+	})
+	return doc, removed, err
+}
+
+// updateParent 找到路径的上级节点并用 fn 修改，数组长度变化时写回上一级
+func updateParent(doc interface{}, tokens []string, fn func(parent interface{}, last string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+
+	head := tokens[0]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[head]
+		if !ok {
+			return nil, fmt.Errorf("字段 %q 不存在", head)
+		}
+		updated, err := updateParent(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[head] = updated
+		return node, nil
+	case []interface{}:
+		index, err := arrayIndex(head, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := updateParent(node[index], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = updated
+		return node, nil
+	}
+	return nil, fmt.Errorf("%q 的上级不是对象或数组", head)
+}
+
+// deepCopy 复制 JSON 值，copy 操作不能与原位置共享对象
+func deepCopy(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			result[k] = deepCopy(child)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for i, child := range val {
+			result[i] = deepCopy(child)
+		}
+		return result
+	}
+	return v
+}
@@ -0,0 +1,217 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// parseJSON 解析测试用例中的 JSON
+func parseJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("JSON %s 格式错误: %v", s, err)
+	}
+	return v
+}
+
+// RFC 7386 附录 A 的示例
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		got := mergePatch(parseJSON(t, tt.target), parseJSON(t, tt.patch))
+		if want := parseJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("mergePatch(%s, %s) = %v，期望 %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
+
+// 用例来自 RFC 6902 附录 A，want 为空表示应返回错误
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		ops  string
+		want string
+	}{
+		{
+			name: "A.1 添加对象成员",
+			doc:  `{"foo":"bar"}`,
+			ops:  `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want: `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name: "A.2 在数组中插入元素",
+			doc:  `{"foo":["bar","baz"]}`,
+			ops:  `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want: `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name: "A.3 删除对象成员",
+			doc:  `{"baz":"qux","foo":"bar"}`,
+			ops:  `[{"op":"remove","path":"/baz"}]`,
+			want: `{"foo":"bar"}`,
+		},
+		{
+			name: "A.4 删除数组元素",
+			doc:  `{"foo":["bar","qux","baz"]}`,
+			ops:  `[{"op":"remove","path":"/foo/1"}]`,
+			want: `{"foo":["bar","baz"]}`,
+		},
+		{
+			name: "A.5 替换值",
+			doc:  `{"baz":"qux","foo":"bar"}`,
+			ops:  `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			want: `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name: "A.6 移动值",
+			doc:  `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			ops:  `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name: "A.7 移动数组元素",
+			doc:  `{"foo":["all","grass","cows","eat"]}`,
+			ops:  `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			want: `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name: "A.8 test 成功",
+			doc:  `{"baz":"qux","foo":["a",2,"c"]}`,
+			ops:  `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			want: `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name: "A.9 test 失败",
+			doc:  `{"baz":"qux"}`,
+			ops:  `[{"op":"test","path":"/baz","value":"bar"}]`,
+		},
+		{
+			name: "A.10 添加嵌套对象",
+			doc:  `{"foo":"bar"}`,
+			ops:  `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			want: `{"foo":"bar","child":{"grandchild":{}}}`,
+		},
+		{
+			name: "A.11 忽略未知字段",
+			doc:  `{"foo":"bar"}`,
+			ops:  `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+			want: `{"foo":"bar","baz":"qux"}`,
+		},
+		{
+			name: "A.12 添加到不存在的位置",
+			doc:  `{"foo":"bar"}`,
+			ops:  `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+		},
+		{
+			name: "A.14 ~ 转义",
+			doc:  `{"/":9,"~1":10}`,
+			ops:  `[{"op":"test","path":"/~01","value":10}]`,
+			want: `{"/":9,"~1":10}`,
+		},
+		{
+			name: "A.15 字符串与数字不相等",
+			doc:  `{"/":9,"~1":10}`,
+			ops:  `[{"op":"test","path":"/~01","value":"10"}]`,
+		},
+		{
+			name: "A.16 添加数组值",
+			doc:  `{"foo":["bar"]}`,
+			ops:  `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			want: `{"foo":["bar",["abc","def"]]}`,
+		},
+		{
+			name: "- 表示数组末尾",
+			doc:  `{"tags":["a"]}`,
+			ops:  `[{"op":"add","path":"/tags/-","value":"b"},{"op":"add","path":"/tags/-","value":"c"}]`,
+			want: `{"tags":["a","b","c"]}`,
+		},
+		{
+			name: "- 不能用于删除",
+			doc:  `{"tags":["a"]}`,
+			ops:  `[{"op":"remove","path":"/tags/-"}]`,
+		},
+		{
+			name: "替换数组元素",
+			doc:  `{"panels":[{"id":1},{"id":2}]}`,
+			ops:  `[{"op":"replace","path":"/panels/1/id","value":3},{"op":"replace","path":"/panels/0","value":{"id":0}}]`,
+			want: `{"panels":[{"id":0},{"id":3}]}`,
+		},
+		{
+			name: "替换不存在的字段",
+			doc:  `{"foo":"bar"}`,
+			ops:  `[{"op":"replace","path":"/baz","value":"qux"}]`,
+		},
+		{
+			name: "复制的值不与原位置共享",
+			doc:  `{"a":{"b":1}}`,
+			ops:  `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			want: `{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{
+			name: "删除不存在的字段",
+			doc:  `{"foo":"bar"}`,
+			ops:  `[{"op":"remove","path":"/baz"}]`,
+		},
+		{
+			name: "数组下标越界",
+			doc:  `{"foo":["bar"]}`,
+			ops:  `[{"op":"add","path":"/foo/2","value":"qux"}]`,
+		},
+		{
+			name: "数组下标不能有前导 0",
+			doc:  `{"foo":["a","b"]}`,
+			ops:  `[{"op":"remove","path":"/foo/01"}]`,
+		},
+		{
+			name: "不支持的操作",
+			doc:  `{}`,
+			ops:  `[{"op":"merge","path":"/a"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, ok := parseJSON(t, tt.ops).([]interface{})
+			if !ok {
+				t.Fatalf("ops 不是数组: %s", tt.ops)
+			}
+
+			got, err := applyJSONPatch(parseJSON(t, tt.doc), ops)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("期望返回错误，得到 %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyJSONPatch: %v", err)
+			}
+			if want := parseJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("结果 = %v，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package dashboard
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// 重新分配的 panel ID 范围，避开片段中手写的小数值 ID
+const (
+	hashedIDBase  = 1000
+	hashedIDRange = 900000
+)
+
+// panelRef 组合后的 panel 及其来源，用于分配 ID
+type panelRef struct {
+	panel *Panel
+	key   string
+}
+
+// assignPanelIDs 检查组合后所有 panel（包括折叠 row 中的子面板）的 ID
+// 首个使用某个 ID 的 panel 保留原 ID；缺少 ID 或与前面重复的 panel 根据片段路径的哈希分配新 ID，
+// 同样的模板每次组合得到相同的 ID，Grafana 中的 panel 链接保持不变
+func assignPanelIDs(panels []*Panel) {
+	refs := collectPanelRefs(panels)
+
+	used := make(map[int]string)
+	var pending []panelRef
+
+	for _, ref := range refs {
+		id := ref.panel.ID
+		if id <= 0 {
+			pending = append(pending, ref)
+			continue
+		}
+		if owner, ok := used[id]; ok {
+			fmt.Printf("⚠️  panel ID %d 重复: %s 与 %s\n", id, owner, ref.key)
+			pending = append(pending, ref)
+			continue
+		}
+		used[id] = ref.key
+	}
+
+	for _, ref := range pending {
+		id := hashedPanelID(ref.key)
+		for {
+			if _, ok := used[id]; !ok {
+				break
+			}
+			id = hashedIDBase + (id-hashedIDBase+1)%hashedIDRange
+		}
+		used[id] = ref.key
+		ref.panel.ID = id
+	}
+}
+
+// collectPanelRefs 按顺序收集所有 panel，来源为片段路径，row 使用标题
+func collectPanelRefs(panels []*Panel) []panelRef {
+	var refs []panelRef
+
+	for _, panel := range panels {
+		key := panel.Title
+		if source := panel.extraString(sourceField); source != "" {
+			key = sourceKey(source)
+		} else if panel.IsRow() {
+			key = "row:" + key
+		}
+		refs = append(refs, panelRef{panel: panel, key: key})
+		refs = append(refs, collectPanelRefs(panel.Panels)...)
+	}
+
+	return refs
+}
+
+// hashedPanelID 根据来源生成稳定的 panel ID
+func hashedPanelID(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return hashedIDBase + int(h.Sum32()%hashedIDRange)
+}
@@ -0,0 +1,108 @@
+package dashboard
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fragment 创建来自片段文件的 panel
+func fragment(id int, source string) *Panel {
+	p := &Panel{ID: id, Type: "timeseries"}
+	p.setExtra(sourceField, source)
+	return p
+}
+
+// panelIDs 按顺序返回所有 panel（包括 row 中的子面板）的 ID
+func panelIDs(panels []*Panel) []int {
+	var ids []int
+	for _, p := range panels {
+		ids = append(ids, p.ID)
+		ids = append(ids, panelIDs(p.Panels)...)
+	}
+	return ids
+}
+
+func TestAssignPanelIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		panels func() []*Panel
+		want   []int
+	}{
+		{
+			name: "不重复的 ID 保持不变",
+			panels: func() []*Panel {
+				return []*Panel{fragment(1, "dashboards/panels/client/a.json"), fragment(2, "dashboards/panels/client/b.json")}
+			},
+			want: []int{1, 2},
+		},
+		{
+			name: "缺少 ID 时按片段路径分配",
+			panels: func() []*Panel {
+				return []*Panel{fragment(0, "dashboards/panels/client/a.json")}
+			},
+			want: []int{hashedPanelID("client/a.json")},
+		},
+		{
+			name: "重复的 ID 由后出现的 panel 重新分配",
+			panels: func() []*Panel {
+				return []*Panel{fragment(3, "dashboards/panels/client/a.json"), fragment(3, "dashboards/panels/server/b.json")}
+			},
+			want: []int{3, hashedPanelID("server/b.json")},
+		},
+		{
+			name: "折叠 row 中的子面板与其他 panel 一起检查",
+			panels: func() []*Panel {
+				r := &Panel{ID: 10, Type: "row", Title: "服务端", Collapsed: true, Panels: []*Panel{
+					fragment(5, "dashboards/panels/server/a.json"),
+					fragment(10, "dashboards/panels/server/b.json"),
+				}}
+				return []*Panel{fragment(5, "dashboards/panels/client/a.json"), r}
+			},
+			want: []int{5, 10, hashedPanelID("server/a.json"), hashedPanelID("server/b.json")},
+		},
+		{
+			name: "哈希得到的 ID 已被使用时顺延",
+			panels: func() []*Panel {
+				taken := hashedPanelID("client/b.json")
+				return []*Panel{fragment(taken, "dashboards/panels/client/a.json"), fragment(0, "dashboards/panels/client/b.json")}
+			},
+			want: []int{hashedPanelID("client/b.json"), hashedPanelID("client/b.json") + 1},
+		},
+		{
+			name: "没有来源的 row 按标题分配",
+			panels: func() []*Panel {
+				return []*Panel{{Type: "row", Title: "客户端"}, fragment(0, "dashboards/panels/client/a.json")}
+			},
+			want: []int{hashedPanelID("row:客户端"), hashedPanelID("client/a.json")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			panels := tt.panels()
+			assignPanelIDs(panels)
+			if got := panelIDs(panels); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("panel ID = %v，期望 %v", got, tt.want)
+			}
+
+			// 同样的输入每次得到相同的 ID
+			again := tt.panels()
+			assignPanelIDs(again)
+			if got := panelIDs(again); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("再次分配得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashedPanelIDRange(t *testing.T) {
+	for _, key := range []string{"", "client/a.json", "server/服务端健康状态.json", "row:客户端"} {
+		id := hashedPanelID(key)
+		if id < hashedIDBase || id >= hashedIDBase+hashedIDRange {
+			t.Errorf("hashedPanelID(%q) = %d，超出范围", key, id)
+		}
+		if id != hashedPanelID(key) {
+			t.Errorf("hashedPanelID(%q) 不稳定", key)
+		}
+	}
+}
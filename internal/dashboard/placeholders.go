@@ -0,0 +1,169 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"tunnel-monitor/internal/config"
+)
+
+// placeholderPattern 匹配模板占位符 {{NAME}}
+// 只匹配大写名称，避免与 legendFormat 中的 {{instance}} 等标签引用冲突
+var placeholderPattern = regexp.MustCompile(`\{\{[A-Z][A-Z0-9_]*\}\}`)
+
+// ResolvePlaceholders 替换 dashboard 中所有字符串里的占位符
+// 包括数据源UID、查询语句、标题、阈值和链接等，值来自数据源配置和 template_vars
+// 字符串只包含一个占位符时按变量的原始类型替换（如阈值为数字），否则按文本拼接
+// 存在未定义的占位符时返回错误，列出占位符及其位置
+func ResolvePlaceholders(dashboard *Dashboard) error {
+	values := config.Global.TemplatePlaceholders()
+	unresolved := make(map[string][]string)
+
+	err := dashboard.transform(func(doc map[string]interface{}) (interface{}, error) {
+		return resolvePlaceholdersRecursive(doc, "", values, unresolved), nil
+	})
+	if err != nil {
+		return fmt.Errorf("替换占位符后的面板无效: %w", err)
+	}
+
+	if len(unresolved) == 0 {
+		return nil
+	}
+
+	var names []string
+	for name := range unresolved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		paths := unresolved[name]
+		sort.Strings(paths)
+		lines = append(lines, fmt.Sprintf("%s（%s）", name, strings.Join(paths, ", ")))
+	}
+	return fmt.Errorf("模板中有未定义的占位符，请在 template_vars 中配置:\n  %s", strings.Join(lines, "\n  "))
+}
+
+// resolvePlaceholdersRecursive 递归替换占位符并返回替换后的值，path 为当前值在 dashboard 中的位置
+func resolvePlaceholdersRecursive(obj interface{}, path string, values map[string]interface{}, unresolved map[string][]string) interface{} {
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = resolvePlaceholdersRecursive(val, joinPath(path, key), values, unresolved)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = resolvePlaceholdersRecursive(item, fmt.Sprintf("%s[%d]", path, i), values, unresolved)
+		}
+	case string:
+		// 整个字符串就是一个占位符时保留变量的类型
+		if placeholderPattern.FindString(v) == v {
+			if value, ok := values[v]; ok {
+				return value
+			}
+		}
+
+		return placeholderPattern.ReplaceAllStringFunc(v, func(placeholder string) string {
+			value, ok := values[placeholder]
+			if !ok {
+				unresolved[placeholder] = append(unresolved[placeholder], path)
+				return placeholder
+			}
+			return fmt.Sprint(value)
+		})
+	}
+	return obj
+}
+
+// joinPath 拼接 JSON 路径
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// writeSplitFile 写入拉取或导入得到的模板文件
+// 原文件中使用了占位符的位置，拉取到的值与占位符的替换结果相同时改回占位符；
+// 值不同时无法判断是否应保留占位符，输出警告，不覆盖该文件
+func writeSplitFile(path string, v interface{}) error {
+	pulled, err := toJSONValue(v)
+	if err != nil {
+		return err
+	}
+
+	if data, err := os.ReadFile(path); err == nil && placeholderPattern.Match(data) {
+		var original interface{}
+		if err := json.Unmarshal(data, &original); err == nil {
+			var conflicts []string
+			pulled = restorePlaceholders(original, pulled, "", config.Global.TemplatePlaceholders(), &conflicts)
+			if len(conflicts) > 0 {
+				fmt.Printf("⚠️  %s 中的占位符与 Grafana 中的值不一致（%s），未覆盖，请手动合并修改\n", path, strings.Join(conflicts, ", "))
+				return nil
+			}
+		}
+	}
+
+	return writeJSONFile(path, pulled)
+}
+
+// restorePlaceholders 按原文件的结构将 pulled 中占位符替换结果还原为占位符，返回还原后的值
+// 原文件中的占位符在 pulled 中没有对应的值或值不同时记录到 conflicts
+func restorePlaceholders(original, pulled interface{}, path string, values map[string]interface{}, conflicts *[]string) interface{} {
+	switch orig := original.(type) {
+	case map[string]interface{}:
+		obj, ok := pulled.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for key, val := range orig {
+			if child, ok := obj[key]; ok {
+				obj[key] = restorePlaceholders(val, child, joinPath(path, key), values, conflicts)
+			} else if containsPlaceholder(val) {
+				*conflicts = append(*conflicts, joinPath(path, key))
+			}
+		}
+		return obj
+	case []interface{}:
+		list, ok := pulled.([]interface{})
+		if !ok {
+			break
+		}
+		for i, val := range orig {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if i < len(list) {
+				list[i] = restorePlaceholders(val, list[i], itemPath, values, conflicts)
+			} else if containsPlaceholder(val) {
+				*conflicts = append(*conflicts, itemPath)
+			}
+		}
+		return list
+	case string:
+		if !placeholderPattern.MatchString(orig) || reflect.DeepEqual(orig, pulled) {
+			return pulled
+		}
+		resolved, err := toJSONValue(resolvePlaceholdersRecursive(orig, path, values, map[string][]string{}))
+		if err == nil && reflect.DeepEqual(resolved, pulled) {
+			return orig
+		}
+		*conflicts = append(*conflicts, path)
+		return pulled
+	}
+
+	if containsPlaceholder(original) {
+		*conflicts = append(*conflicts, path)
+	}
+	return pulled
+}
+
+// containsPlaceholder 判断 JSON 值中是否有字符串包含占位符
+func containsPlaceholder(v interface{}) bool {
+	data, err := json.Marshal(v)
+	return err == nil && placeholderPattern.Match(data)
+}
@@ -0,0 +1,149 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tunnel-monitor/internal/config"
+)
+
+// setTemplateVars 使用给定的 template_vars 和数据源UID作为全局配置
+func setTemplateVars(t *testing.T, vars map[string]interface{}) {
+	t.Helper()
+
+	previous := config.Global
+	t.Cleanup(func() { config.Global = previous })
+
+	config.Global = &config.Config{TemplateVars: vars}
+	config.Global.Grafana.PrometheusUID = "prom"
+	config.Global.MySQL.UID = "mysql"
+}
+
+func TestWriteSplitFileRestoresPlaceholders(t *testing.T) {
+	setTemplateVars(t, map[string]interface{}{"REFRESH": "10s", "LATENCY_CRITICAL_MS": 80})
+
+	tests := []struct {
+		name     string
+		original string
+		pulled   string
+		want     string // 为空表示文件未被覆盖
+	}{
+		{
+			name:     "值与替换结果相同时还原占位符",
+			original: `{"refresh": "{{REFRESH}}", "thresholds": {"steps": [{"value": null}, {"value": "{{LATENCY_CRITICAL_MS}}"}]}}`,
+			pulled:   `{"refresh": "10s", "thresholds": {"steps": [{"value": null}, {"value": 80}]}, "version": 3}`,
+			want:     `{"refresh": "{{REFRESH}}", "thresholds": {"steps": [{"value": null}, {"value": "{{LATENCY_CRITICAL_MS}}"}]}, "version": 3}`,
+		},
+		{
+			name:     "字符串中的部分占位符",
+			original: `{"title": "延迟超过 {{LATENCY_CRITICAL_MS}}ms"}`,
+			pulled:   `{"title": "延迟超过 80ms"}`,
+			want:     `{"title": "延迟超过 {{LATENCY_CRITICAL_MS}}ms"}`,
+		},
+		{
+			name:     "数据源占位符已经还原",
+			original: `{"datasource": {"type": "prometheus", "uid": "{{PROMETHEUS_UID}}"}}`,
+			pulled:   `{"datasource": {"type": "prometheus", "uid": "{{PROMETHEUS_UID}}"}}`,
+			want:     `{"datasource": {"type": "prometheus", "uid": "{{PROMETHEUS_UID}}"}}`,
+		},
+		{
+			name:     "值在 Grafana 中被修改时不覆盖",
+			original: `{"refresh": "{{REFRESH}}"}`,
+			pulled:   `{"refresh": "30s"}`,
+		},
+		{
+			name:     "使用占位符的字段在 Grafana 中被删除时不覆盖",
+			original: `{"thresholds": {"steps": [{"value": null}, {"value": "{{LATENCY_CRITICAL_MS}}"}]}}`,
+			pulled:   `{"thresholds": {"steps": [{"value": null}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "panel.json")
+			if err := os.WriteFile(path, []byte(tt.original), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var pulled interface{}
+			if err := json.Unmarshal([]byte(tt.pulled), &pulled); err != nil {
+				t.Fatal(err)
+			}
+			if err := writeSplitFile(path, pulled); err != nil {
+				t.Fatalf("writeSplitFile: %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if string(data) != tt.original {
+					t.Errorf("文件被覆盖为 %s", data)
+				}
+				return
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("写入 %s，期望 %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSplitFileNewFile(t *testing.T) {
+	setTemplateVars(t, map[string]interface{}{"REFRESH": "10s"})
+
+	path := filepath.Join(t.TempDir(), "panel.json")
+	if err := writeSplitFile(path, map[string]interface{}{"refresh": "10s"}); err != nil {
+		t.Fatalf("writeSplitFile: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"refresh": "10s"`) {
+		t.Errorf("新文件应保留拉取到的值，得到 %s", data)
+	}
+}
+
+func TestDatasourcePlaceholder(t *testing.T) {
+	setTemplateVars(t, nil)
+	// 两个数据源使用相同的UID时按类型区分
+	config.Global.MySQL.UID = "prom"
+
+	tests := []struct {
+		ds   map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"type": "prometheus", "uid": "prom"}, "{{PROMETHEUS_UID}}"},
+		{map[string]interface{}{"type": "mysql", "uid": "prom"}, "{{MYSQL_UID}}"},
+		{map[string]interface{}{"uid": "prom"}, "{{PROMETHEUS_UID}}"},
+		{map[string]interface{}{"type": "mysql", "uid": "other-mysql"}, "{{MYSQL_UID}}"},
+		{map[string]interface{}{"type": "loki", "uid": "prom"}, "{{PROMETHEUS_UID}}"},
+		{map[string]interface{}{"type": "loki", "uid": "loki"}, ""},
+		{map[string]interface{}{"type": "prometheus", "uid": "${DS_PROMETHEUS}"}, ""},
+		{map[string]interface{}{"type": "prometheus", "uid": "{{PROMETHEUS_UID}}"}, ""},
+	}
+
+	for _, tt := range tests {
+		// 多次执行结果相同，不依赖 map 的遍历顺序
+		for i := 0; i < 20; i++ {
+			if got := datasourcePlaceholder(tt.ds); got != tt.want {
+				t.Fatalf("datasourcePlaceholder(%v) = %q，期望 %q", tt.ds, got, tt.want)
+			}
+		}
+	}
+}
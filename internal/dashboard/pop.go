@@ -0,0 +1,367 @@
+package dashboard
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"tunnel-monitor/internal/config"
+	"tunnel-monitor/internal/grafana"
+)
+
+// POP 来源
+const (
+	POPSourceMySQL      = "mysql"
+	POPSourcePrometheus = "prometheus"
+)
+
+// popDetailManifest 按 POP 生成的面板清单名称
+const popDetailManifest = "pop-detail"
+
+// popDetailTag 按 POP 生成的面板都带有该标签，清理已下线 POP 的面板时只删除带标签的面板
+const popDetailTag = "pop-detail"
+
+// popQuery 从业务库查询所有 POP，exported_instance 与 intra_ip 一致
+const popQuery = "SELECT machine_code, alias, intra_ip FROM machines WHERE type='pop' AND deleted_at IS NULL ORDER BY machine_code"
+
+// popPromQuery 最近一天上报过指标的 POP，短暂离线的 POP 不会被当作已下线
+const popPromQuery = "group by (exported_instance) (last_over_time(pop_alive_status[1d]))"
+
+// maxUIDLength Grafana dashboard UID 的最大长度
+const maxUIDLength = 40
+
+// uidInvalidChars dashboard UID 只能包含字母、数字、- 和 _
+var uidInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// POP 一台 POP 机器
+type POP struct {
+	Code     string // 机器编码，用于生成面板UID
+	Name     string // 显示名称，用于面板标题
+	Instance string // 指标中的 exported_instance，锁定变量的值
+}
+
+// PopDetailsOptions 按 POP 创建面板的选项
+type PopDetailsOptions struct {
+	CreateOptions
+	Source string // POP 来源：mysql 或 prometheus，默认启用 MySQL 时为 mysql
+}
+
+// CreatePopDetailDashboards 为每个 POP 创建一个详情面板，并删除已下线 POP 的面板
+func CreatePopDetailDashboards(opts PopDetailsOptions) error {
+	m, err := LoadManifest(popDetailManifest)
+	if err != nil {
+		return err
+	}
+	if m.PerPOP == nil {
+		return fmt.Errorf("面板清单 %s 没有 per_pop 配置，不能按 POP 生成", m.Name)
+	}
+
+	pops, err := DiscoverPOPs(opts.Source)
+	if err != nil {
+		return err
+	}
+	// 发现结果为空时通常是查询出错，不能据此删除所有面板
+	if len(pops) == 0 {
+		return fmt.Errorf("未发现任何 POP，未做任何修改")
+	}
+	fmt.Printf("🔍 发现 %d 个 POP\n", len(pops))
+	fmt.Println()
+
+	tm := NewTemplateManager(config.Global.Dashboards.Dir)
+	tm.Lenient = opts.Lenient
+	tm.Env = opts.Env
+	if tm.files.Embedded() {
+		fmt.Printf("📦 %s 不存在，使用内置模板\n", config.Global.Dashboards.Dir)
+	}
+
+	prefix := dashboardUID(m) + "-"
+	keep := make(map[string]bool)
+
+	for _, pop := range pops {
+		dashboard, err := renderDashboard(tm, m)
+		if err != nil {
+			return err
+		}
+		if err := applyPOP(dashboard, m, pop); err != nil {
+			return err
+		}
+		if keep[dashboard.UID] {
+			return fmt.Errorf("POP %s 的面板UID %s 与其他 POP 重复", pop.Code, dashboard.UID)
+		}
+		keep[dashboard.UID] = true
+
+		libraryPanels, err := finalizeDashboard(dashboard, opts.useLibraryPanels())
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("📊 创建%s面板...\n", dashboard.Title)
+		if opts.DryRun {
+			if err := previewDashboard(dashboard, m, libraryPanels); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := importToFolder(dashboard, m, versionMessage(tm), libraryPanels); err != nil {
+			return err
+		}
+	}
+	fmt.Println()
+
+	if err := prunePopDashboards(prefix, keep, opts.DryRun); err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		fmt.Println("✅ dry-run 完成，未修改任何面板")
+		return nil
+	}
+	fmt.Printf("✅ 已创建 %d 个 POP 详情面板\n", len(pops))
+	return nil
+}
+
+// applyPOP 设置 POP 面板的 UID、标题和标签，并锁定清单中声明的变量
+// exported_instance 也作为标签，可以在面板列表中按 POP 搜索
+func applyPOP(dashboard *Dashboard, m *Manifest, pop POP) error {
+	dashboard.UID = popDashboardUID(dashboard.UID, pop.Code)
+	dashboard.Title = fmt.Sprintf("%s - %s", dashboard.Title, pop.Name)
+	addTag(dashboard, popDetailTag)
+	addTag(dashboard, pop.Instance)
+
+	for _, name := range m.PerPOP.Variables {
+		v := findVariable(dashboard, name)
+		if v == nil {
+			return fmt.Errorf("面板清单 %s 的 per_pop 变量 %s 在基础模板中不存在", m.Name, name)
+		}
+		lockVariable(v, pop)
+	}
+	return nil
+}
+
+// popDashboardUID 由面板清单的 UID 和机器编码生成 POP 面板UID
+// 超过 Grafana 长度限制时截断并加上机器编码的摘要，保证不同 POP 的 UID 不会相同
+func popDashboardUID(prefix, code string) string {
+	uid := prefix + "-" + uidInvalidChars.ReplaceAllString(code, "-")
+	if len(uid) <= maxUIDLength {
+		return uid
+	}
+
+	sum := sha1.Sum([]byte(code))
+	hash := hex.EncodeToString(sum[:])[:8]
+	return uid[:maxUIDLength-len(hash)-1] + "-" + hash
+}
+
+// findVariable 按名称查找变量
+func findVariable(dashboard *Dashboard, name string) *TemplateVar {
+	for _, v := range dashboard.Variables() {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// lockVariable 将变量改为隐藏的常量，值为 POP 的 exported_instance
+func lockVariable(v *TemplateVar, pop POP) {
+	option := map[string]interface{}{
+		"selected": true,
+		"text":     pop.Name,
+		"value":    pop.Instance,
+	}
+
+	v.Type = "constant"
+	v.Query = pop.Instance
+	if v.Extra == nil {
+		v.Extra = make(map[string]interface{})
+	}
+	v.Extra["hide"] = 2
+	v.Extra["current"] = option
+	v.Extra["options"] = []interface{}{option}
+	for _, key := range []string{"datasource", "definition", "includeAll", "multi", "refresh", "regex", "sort"} {
+		delete(v.Extra, key)
+	}
+}
+
+// addTag 为面板添加标签，已存在时不重复添加
+func addTag(dashboard *Dashboard, tag string) {
+	tags, _ := dashboard.Extra["tags"].([]interface{})
+	for _, t := range tags {
+		if t == tag {
+			return
+		}
+	}
+	if dashboard.Extra == nil {
+		dashboard.Extra = make(map[string]interface{})
+	}
+	dashboard.Extra["tags"] = append(tags, tag)
+}
+
+// prunePopDashboards 删除已下线 POP 的面板：UID 以 prefix 开头、带有 pop-detail 标签且不在 keep 中
+func prunePopDashboards(prefix string, keep map[string]bool, dryRun bool) error {
+	client := grafana.NewClient()
+
+	var dashboards []map[string]interface{}
+	path := "/api/search?type=dash-db&tag=" + url.QueryEscape(popDetailTag)
+	if err := client.Get(path, &dashboards); err != nil {
+		return fmt.Errorf("获取 POP 详情面板列表失败: %w", err)
+	}
+
+	for _, db := range dashboards {
+		uid := getString(db, "uid")
+		if !strings.HasPrefix(uid, prefix) || keep[uid] {
+			continue
+		}
+
+		title := getString(db, "title")
+		if dryRun {
+			fmt.Printf("🔍 [dry-run] 将删除已下线 POP 的面板 %s（%s）\n", title, uid)
+			continue
+		}
+		if err := client.Delete("/api/dashboards/uid/" + url.PathEscape(uid)); err != nil && !grafana.IsNotFound(err) {
+			return fmt.Errorf("删除面板 %s 失败: %w", uid, err)
+		}
+		fmt.Printf("🗑️ 已删除已下线 POP 的面板 %s（%s）\n", title, uid)
+	}
+
+	return nil
+}
+
+// DiscoverPOPs 从指定来源获取 POP 列表，按机器编码排序
+// source 为空时启用 MySQL 的部署从 MySQL 获取，否则从 Prometheus 获取
+func DiscoverPOPs(source string) ([]POP, error) {
+	if source == "" {
+		source = POPSourcePrometheus
+		if config.Global.Features["MySQL"] {
+			source = POPSourceMySQL
+		}
+	}
+
+	var pops []POP
+	var err error
+	switch source {
+	case POPSourceMySQL:
+		pops, err = discoverPOPsFromMySQL()
+	case POPSourcePrometheus:
+		pops, err = discoverPOPsFromPrometheus()
+	default:
+		return nil, fmt.Errorf("不支持的 POP 来源 %q（可选 mysql、prometheus）", source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("从 %s 获取 POP 列表失败: %w", source, err)
+	}
+
+	sort.Slice(pops, func(i, j int) bool {
+		return pops[i].Code < pops[j].Code
+	})
+	return pops, nil
+}
+
+// dsQueryResponse Grafana /api/ds/query 的响应，每列的值按列存放
+type dsQueryResponse struct {
+	Results map[string]struct {
+		Error  string `json:"error"`
+		Frames []struct {
+			Schema struct {
+				Fields []struct {
+					Name string `json:"name"`
+				} `json:"fields"`
+			} `json:"schema"`
+			Data struct {
+				Values [][]interface{} `json:"values"`
+			} `json:"data"`
+		} `json:"frames"`
+	} `json:"results"`
+}
+
+// discoverPOPsFromMySQL 通过 Grafana 的 MySQL 数据源查询 machines 表
+func discoverPOPsFromMySQL() ([]POP, error) {
+	payload := map[string]interface{}{
+		"from": "now-5m",
+		"to":   "now",
+		"queries": []interface{}{
+			map[string]interface{}{
+				"refId": "A",
+				"datasource": map[string]interface{}{
+					"type": "mysql",
+					"uid":  config.Global.MySQL.UID,
+				},
+				"rawSql": popQuery,
+				"format": "table",
+			},
+		},
+	}
+
+	var result dsQueryResponse
+	if err := grafana.NewClient().Post("/api/ds/query", payload, &result); err != nil {
+		return nil, err
+	}
+
+	res, ok := result.Results["A"]
+	if !ok {
+		return nil, fmt.Errorf("响应中没有查询结果")
+	}
+	if res.Error != "" {
+		return nil, fmt.Errorf("%s", res.Error)
+	}
+
+	var pops []POP
+	for _, frame := range res.Frames {
+		columns := make(map[string][]interface{})
+		for i, field := range frame.Schema.Fields {
+			if i < len(frame.Data.Values) {
+				columns[field.Name] = frame.Data.Values[i]
+			}
+		}
+
+		for i := range columns["machine_code"] {
+			pop := POP{
+				Code:     columnString(columns["machine_code"], i),
+				Name:     columnString(columns["alias"], i),
+				Instance: columnString(columns["intra_ip"], i),
+			}
+			if pop.Code == "" || pop.Instance == "" {
+				continue
+			}
+			if pop.Name == "" {
+				pop.Name = pop.Code
+			}
+			pops = append(pops, pop)
+		}
+	}
+
+	return pops, nil
+}
+
+// columnString 返回列中第 i 个值，NULL 返回空字符串
+func columnString(values []interface{}, i int) string {
+	if i >= len(values) || values[i] == nil {
+		return ""
+	}
+	return fmt.Sprint(values[i])
+}
+
+// discoverPOPsFromPrometheus 从 Prometheus 中 pop_alive_status 的 exported_instance 标签获取 POP
+// 没有机器编码，使用 exported_instance 作为编码和名称
+func discoverPOPsFromPrometheus() ([]POP, error) {
+	queryURL := fmt.Sprintf("%s/api/v1/query?query=%s", config.Global.Prometheus.URL, url.QueryEscape(popPromQuery))
+
+	var result queryResponse
+	if err := getPrometheusJSON(queryURL, &result); err != nil {
+		return nil, err
+	}
+
+	var pops []POP
+	for _, r := range result.Data.Result {
+		instance := r.Metric["exported_instance"]
+		if instance == "" {
+			continue
+		}
+		pops = append(pops, POP{Code: instance, Name: instance, Instance: instance})
+	}
+
+	return pops, nil
+}
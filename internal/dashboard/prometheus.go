@@ -10,45 +10,74 @@ import (
 	"tunnel-monitor/internal/config"
 )
 
-// GetClientInstances 从 Prometheus targets API 获取所有客户端实例（包括 up 和 down）
-func GetClientInstances() ([]string, error) {
-	// 从 Prometheus targets API 获取所有配置的 target，包括 down 的
-	promURL := config.Global.Prometheus.URL
-	url := fmt.Sprintf("%s/api/v1/targets", promURL)
+// targetsResponse Prometheus /api/v1/targets 的响应
+type targetsResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ActiveTargets []struct {
+			Labels map[string]string `json:"labels"`
+			Health string            `json:"health"`
+		} `json:"activeTargets"`
+	} `json:"data"`
+}
+
+// queryResponse Prometheus /api/v1/query 的响应（instant vector）
+type queryResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		Result []struct {
+			Metric map[string]string `json:"metric"`
+		} `json:"result"`
+	} `json:"data"`
+}
 
+// getPrometheusJSON 请求 Prometheus API 并解析响应，status 不是 success 时返回错误
+func getPrometheusJSON(url string, v interface{}) error {
 	resp, err := http.Get(url)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, err
+	var status struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return fmt.Errorf("解析 Prometheus 响应失败: %w", err)
+	}
+	if status.Status != "success" {
+		return fmt.Errorf("查询失败: %s", data)
 	}
 
-	status := getString(result, "status")
-	if status != "success" {
-		return nil, fmt.Errorf("查询失败: %v", result)
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析 Prometheus 响应失败: %w", err)
 	}
+	return nil
+}
+
+// GetClientInstances 从 Prometheus targets API 获取所有客户端实例（包括 up 和 down）
+func GetClientInstances() ([]string, error) {
+	// 从 Prometheus targets API 获取所有配置的 target，包括 down 的
+	promURL := config.Global.Prometheus.URL
+	url := fmt.Sprintf("%s/api/v1/targets", promURL)
 
-	dataObj := result["data"].(map[string]interface{})
-	activeTargets := dataObj["activeTargets"].([]interface{})
+	var result targetsResponse
+	if err := getPrometheusJSON(url, &result); err != nil {
+		return nil, err
+	}
 
 	instances := make(map[string]bool)
-	for _, target := range activeTargets {
-		targetMap := target.(map[string]interface{})
-		labels := targetMap["labels"].(map[string]interface{})
-		job := getString(labels, "job")
+	for _, target := range result.Data.ActiveTargets {
+		job := target.Labels["job"]
 
 		// 获取所有 tunnel-client-pop job 的目标，不管健康状态如何（up/down/unknown）
 		if job == "tunnel-client-pop" {
-			instance := getString(labels, "instance")
+			instance := target.Labels["instance"]
 			if instance != "" && strings.Contains(instance, ":") {
 				instances[instance] = true
 			}
@@ -69,40 +98,19 @@ func GetServerInstances() ([]string, error) {
 	promURL := config.Global.Prometheus.URL
 	url := fmt.Sprintf("%s/api/v1/targets", promURL)
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
+	var result targetsResponse
+	if err := getPrometheusJSON(url, &result); err != nil {
 		return nil, err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, err
-	}
-
-	status := getString(result, "status")
-	if status != "success" {
-		return nil, fmt.Errorf("查询失败: %v", result)
-	}
-
-	dataObj := result["data"].(map[string]interface{})
-	activeTargets := dataObj["activeTargets"].([]interface{})
-
 	instances := make(map[string]bool)
-	for _, target := range activeTargets {
-		targetMap := target.(map[string]interface{})
-		labels := targetMap["labels"].(map[string]interface{})
-		job := getString(labels, "job")
-		health := getString(targetMap, "health")
+	for _, target := range result.Data.ActiveTargets {
+		job := target.Labels["job"]
+		health := target.Health
 
 		// 只获取服务端 job 且健康的目标
 		if job == "tunnel-server" && (health == "up" || health == "unknown") {
-			instance := getString(labels, "instance")
+			instance := target.Labels["instance"]
 			if instance != "" && strings.Contains(instance, ":") {
 				instances[instance] = true
 			}
@@ -115,24 +123,12 @@ func GetServerInstances() ([]string, error) {
 		encodedQuery := strings.ReplaceAll(query, " ", "%20")
 		queryURL := fmt.Sprintf("%s/api/v1/query?query=%s", promURL, encodedQuery)
 
-		resp, err := http.Get(queryURL)
-		if err == nil {
-			defer resp.Body.Close()
-			data, err := io.ReadAll(resp.Body)
-			if err == nil {
-				var queryResult map[string]interface{}
-				if json.Unmarshal(data, &queryResult) == nil {
-					if getString(queryResult, "status") == "success" {
-						dataObj := queryResult["data"].(map[string]interface{})
-						results := dataObj["result"].([]interface{})
-						for _, r := range results {
-							metric := r.(map[string]interface{})["metric"].(map[string]interface{})
-							instance := getString(metric, "instance")
-							if instance != "" && strings.Contains(instance, ":") {
-								instances[instance] = true
-							}
-						}
-					}
+		var queryResult queryResponse
+		if getPrometheusJSON(queryURL, &queryResult) == nil {
+			for _, r := range queryResult.Data.Result {
+				instance := r.Metric["instance"]
+				if instance != "" && strings.Contains(instance, ":") {
+					instances[instance] = true
 				}
 			}
 		}
@@ -0,0 +1,99 @@
+package dashboard
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"tunnel-monitor/internal/config"
+)
+
+// PullOptions dashboard pull 的输出位置
+type PullOptions struct {
+	Name      string // 面板清单名称；没有对应清单时输出到 dashboards/<name>-base.json 和 dashboards/panels/<name>
+	BasePath  string // 基础模板输出路径，优先于 Name
+	PanelsDir string // panels片段输出目录，优先于 Name
+}
+
+// volatileFields Grafana 在保存时维护的字段，不应写入模板
+var volatileFields = []string{"id", "version", "iteration"}
+
+// PullDashboard 从 Grafana 拉取 dashboard 并拆分为基础模板和panels片段
+func PullDashboard(uid string, opts PullOptions) error {
+	fmt.Printf("📥 拉取面板 %s...\n", uid)
+
+	basePath, panelsDir, err := resolvePullTarget(uid, opts)
+	if err != nil {
+		return err
+	}
+
+	dashboard, _, err := GetDashboardByUID(uid)
+	if err != nil {
+		return fmt.Errorf("获取面板失败: %w", err)
+	}
+
+	// 移除 Grafana 维护的字段，还原数据源占位符
+	normalizeExport(dashboard)
+
+	tm := NewTemplateManager("")
+	result, err := tm.SplitDashboard(dashboard, basePath, panelsDir)
+	if err != nil {
+		return fmt.Errorf("拆分面板失败: %w", err)
+	}
+
+	fmt.Printf("✅ 基础模板: %s\n", basePath)
+	fmt.Printf("✅ panels目录: %s\n", panelsDir)
+	for _, file := range result.Stale {
+		fmt.Printf("⚠️ %s 在 Grafana 面板中已不存在，如已删除请手动移除该文件\n", file)
+	}
+
+	return nil
+}
+
+// resolvePullTarget 确定拉取结果的输出位置
+// 与面板清单 UID 相同的面板写回清单引用的基础模板和第一个panels目录
+func resolvePullTarget(uid string, opts PullOptions) (string, string, error) {
+	basePath, panelsDir := opts.BasePath, opts.PanelsDir
+
+	var matched *Manifest
+	if manifests, err := LoadManifests(); err == nil {
+		for _, m := range manifests {
+			if (opts.Name == "" && dashboardUID(m) == uid) || (opts.Name != "" && m.Name == opts.Name) {
+				matched = m
+				break
+			}
+		}
+	}
+
+	tm := NewTemplateManager(config.Global.Dashboards.Dir)
+	if tm.files.Embedded() && (basePath == "" || panelsDir == "") {
+		// 只写入拉取的文件会得到不完整的模板目录，之后将不再使用内置模板
+		return "", "", fmt.Errorf("模板目录 %s 不存在，请先运行 dashboard templates extract --out %s 导出内置模板", config.Global.Dashboards.Dir, config.Global.Dashboards.Dir)
+	}
+
+	switch {
+	case matched != nil:
+		if basePath == "" {
+			basePath = tm.resolvePath(matched.Base)
+		}
+		if dirs := matched.panelDirs(); panelsDir == "" && len(dirs) > 0 {
+			panelsDir = tm.resolvePath(dirs[0])
+		}
+	case opts.Name != "":
+		if basePath == "" {
+			basePath = tm.resolvePath(opts.Name + "-base.json")
+		}
+		if panelsDir == "" {
+			panelsDir = tm.resolvePath(filepath.Join("panels", opts.Name))
+		}
+	}
+
+	if basePath == "" || panelsDir == "" {
+		return "", "", fmt.Errorf("面板 %s 没有对应的面板清单，请使用 --name 指定模板名称", uid)
+	}
+	// 目录在磁盘上创建后不再读取内置模板，其中其他清单引用的片段将不可用
+	if tm.files.shadowsEmbedded(panelsDir) {
+		return "", "", fmt.Errorf("panels目录 %s 当前使用内置模板，请先运行 dashboard templates extract --out %s 导出内置模板", panelsDir, config.Global.Dashboards.Dir)
+	}
+
+	return basePath, panelsDir, nil
+}
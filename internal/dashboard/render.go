@@ -0,0 +1,161 @@
+package dashboard
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+	"tunnel-monitor/internal/config"
+	"tunnel-monitor/internal/datasource"
+)
+
+// RenderOptions dashboard render 的输出选项
+type RenderOptions struct {
+	OutDir         string // 输出目录
+	DashboardsPath string // Grafana 读取 dashboard JSON 的目录，默认为 <OutDir>/dashboards 的绝对路径
+	Lenient        bool   // 跳过无法加载的panels片段，只输出警告
+	Env            string // 环境名称，应用 overlays/<环境> 中的覆盖配置
+}
+
+// dashboardProvider Grafana dashboard provisioning 中的一个 provider
+type dashboardProvider struct {
+	Name                  string            `yaml:"name"`
+	OrgID                 int               `yaml:"orgId"`
+	Folder                string            `yaml:"folder"`
+	Type                  string            `yaml:"type"`
+	DisableDeletion       bool              `yaml:"disableDeletion"`
+	AllowUIUpdates        bool              `yaml:"allowUiUpdates"`
+	UpdateIntervalSeconds int               `yaml:"updateIntervalSeconds"`
+	Options               map[string]string `yaml:"options"`
+}
+
+// RenderProvisioning 将所有面板渲染为 Grafana 文件 provisioning 格式
+// 输出目录结构：
+//
+//	<out>/dashboards/<文件夹>/<uid>.json
+//	<out>/provisioning/dashboards/dashboards.yaml
+//	<out>/provisioning/datasources/datasources.yaml
+//
+// Grafana 启动时即可加载，不需要 API 凭据
+func RenderProvisioning(opts RenderOptions) error {
+	if opts.OutDir == "" {
+		return fmt.Errorf("请使用 --out 指定输出目录")
+	}
+
+	fmt.Printf("📦 渲染 provisioning 文件到 %s...\n", opts.OutDir)
+
+	dashboardsPath := opts.DashboardsPath
+	if dashboardsPath == "" {
+		absOut, err := filepath.Abs(opts.OutDir)
+		if err != nil {
+			return err
+		}
+		dashboardsPath = filepath.Join(absOut, "dashboards")
+	}
+
+	// 每个文件夹对应一个 provider，按首次出现的顺序输出
+	providers := make(map[string]*dashboardProvider)
+	var folders []string
+
+	manifests, err := LoadManifests()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range manifests {
+		tm := NewTemplateManager(config.Global.Dashboards.Dir)
+		tm.Lenient = opts.Lenient
+		tm.Env = opts.Env
+		dashboard, err := renderDashboard(tm, m)
+		if err != nil {
+			return err
+		}
+		// 库面板需要通过 API 创建，provisioning 文件中始终内联 panel
+		if _, err := finalizeDashboard(dashboard, false); err != nil {
+			return err
+		}
+
+		folder := dashboardFolder(m)
+		folderDir := sanitizeFileName(folderDisplayName(folder))
+
+		if _, ok := providers[folder]; !ok {
+			providers[folder] = &dashboardProvider{
+				Name:                  "tunnel-monitor-" + folderDir,
+				OrgID:                 1,
+				Folder:                folder,
+				Type:                  "file",
+				UpdateIntervalSeconds: 30,
+				Options: map[string]string{
+					"path": filepath.ToSlash(filepath.Join(dashboardsPath, folderDir)),
+				},
+			}
+			folders = append(folders, folder)
+		}
+
+		dir := filepath.Join(opts.OutDir, "dashboards", folderDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建目录 %s 失败: %w", dir, err)
+		}
+
+		// provisioning 的 dashboard 由 Grafana 分配 id
+		delete(dashboard.Extra, "id")
+
+		file := filepath.Join(dir, dashboard.UID+".json")
+		if err := writeJSONFile(file, dashboard); err != nil {
+			return fmt.Errorf("写入面板文件 %s 失败: %w", file, err)
+		}
+		fmt.Printf("✅ %s\n", file)
+	}
+
+	providerList := make([]*dashboardProvider, 0, len(folders))
+	for _, folder := range folders {
+		providerList = append(providerList, providers[folder])
+	}
+
+	dashboardsYAML := filepath.Join(opts.OutDir, "provisioning", "dashboards", "dashboards.yaml")
+	if err := writeYAMLFile(dashboardsYAML, map[string]interface{}{
+		"apiVersion": 1,
+		"providers":  providerList,
+	}, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("✅ %s\n", dashboardsYAML)
+
+	// 数据源文件包含数据库密码，限制读取权限
+	datasourcesYAML := filepath.Join(opts.OutDir, "provisioning", "datasources", "datasources.yaml")
+	if err := writeYAMLFile(datasourcesYAML, map[string]interface{}{
+		"apiVersion":  1,
+		"datasources": datasource.Definitions(),
+	}, 0640); err != nil {
+		return err
+	}
+	fmt.Printf("✅ %s\n", datasourcesYAML)
+
+	fmt.Println("💡 提示：")
+	fmt.Println("   - 将 provisioning 目录内容复制到 /etc/grafana/provisioning")
+	fmt.Printf("   - 确保 dashboard 文件位于 %s\n", dashboardsPath)
+	return nil
+}
+
+// writeYAMLFile 写入 YAML 文件，自动创建所在目录
+func writeYAMLFile(path string, v interface{}, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化 %s 失败: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", path, err)
+	}
+	// WriteFile 不会修改已有文件的权限
+	if err := os.Chmod(path, perm); err != nil {
+		return fmt.Errorf("设置 %s 权限失败: %w", path, err)
+	}
+
+	return nil
+}
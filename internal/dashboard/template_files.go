@@ -0,0 +1,219 @@
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tunnel-monitor/dashboards"
+)
+
+// templateFiles 模板文件的来源
+// dashboards.dir 在磁盘上存在时读取磁盘上的文件，否则使用编译进二进制的内置模板；
+// dashboards.dir 中缺少的子目录（如只定制了 manifests 时的 panels）整个使用内置模板
+type templateFiles struct {
+	dir      string
+	embedded fs.FS // nil 表示只读取磁盘上的文件
+	onDisk   bool  // dashboards.dir 在磁盘上存在
+}
+
+// openTemplateFiles 打开模板目录，目录不存在时使用内置模板
+func openTemplateFiles(dir string) *templateFiles {
+	if dir == "" {
+		return &templateFiles{}
+	}
+	info, err := os.Stat(dir)
+	return &templateFiles{dir: dir, embedded: dashboards.FS, onDisk: err == nil && info.IsDir()}
+}
+
+// Embedded 模板目录在磁盘上不存在，所有文件都使用内置模板
+func (f *templateFiles) Embedded() bool {
+	return f.embedded != nil && !f.onDisk
+}
+
+// relPath 返回 path 相对于模板目录的路径（使用 / 分隔），不在模板目录下时返回 false
+func (f *templateFiles) relPath(path string) (string, bool) {
+	rel, err := filepath.Rel(f.dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// embeddedPath 判断 dir 目录下的文件是否使用内置模板，返回 path 在内置模板中的路径
+// 路径不在模板目录下或 dir 在磁盘上存在时返回 false，直接读取磁盘；
+// 磁盘上的目录优先，其中删除的文件不会从内置模板中补回
+func (f *templateFiles) embeddedPath(dir, path string) (string, bool) {
+	if f.embedded == nil {
+		return "", false
+	}
+	rel, ok := f.relPath(path)
+	if !ok {
+		return "", false
+	}
+	if f.onDisk {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return "", false
+		}
+	}
+	return rel, true
+}
+
+// shadowsEmbedded 目录当前使用内置模板，在磁盘上创建后将只读取磁盘上的文件
+func (f *templateFiles) shadowsEmbedded(dir string) bool {
+	rel, ok := f.embeddedPath(dir, dir)
+	if !ok {
+		return false
+	}
+	info, err := fs.Stat(f.embedded, rel)
+	return err == nil && info.IsDir()
+}
+
+// ReadFile 读取模板文件
+func (f *templateFiles) ReadFile(path string) ([]byte, error) {
+	if rel, ok := f.embeddedPath(filepath.Dir(path), path); ok {
+		return fs.ReadFile(f.embedded, rel)
+	}
+	return os.ReadFile(path)
+}
+
+// ReadDir 读取模板目录，按文件名排序
+// 读取磁盘上的目录时，磁盘上不存在的子目录使用内置模板，因此也列出内置模板中的这些子目录
+func (f *templateFiles) ReadDir(path string) ([]fs.DirEntry, error) {
+	if rel, ok := f.embeddedPath(path, path); ok {
+		return fs.ReadDir(f.embedded, rel)
+	}
+
+	entries, err := os.ReadDir(path)
+	rel, ok := f.relPath(path)
+	if err != nil || f.embedded == nil || !ok {
+		return entries, err
+	}
+
+	embedded, err := fs.ReadDir(f.embedded, rel)
+	if err != nil {
+		// 目录只存在于磁盘上
+		return entries, nil
+	}
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+	for _, entry := range embedded {
+		if entry.IsDir() && !names[entry.Name()] {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// Glob 返回匹配的模板文件，按文件名排序
+// 按 pattern 中第一个含通配符的部分之前的目录决定读取磁盘还是内置模板
+func (f *templateFiles) Glob(pattern string) ([]string, error) {
+	rel, ok := f.embeddedPath(globDir(pattern), pattern)
+	if !ok {
+		return filepath.Glob(pattern)
+	}
+
+	matches, err := fs.Glob(f.embedded, rel)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range matches {
+		matches[i] = filepath.Join(f.dir, filepath.FromSlash(m))
+	}
+	return matches, nil
+}
+
+// globDir 返回 pattern 中不含通配符的目录部分，如 panels/client/*.json 返回 panels/client
+func globDir(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, `*?[\`) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// WalkDir 遍历模板目录下的文件和子目录（不包括 root 本身），每个目录按 ReadDir 的规则读取
+// 回调中的路径以模板目录开头
+func (f *templateFiles) WalkDir(root string, fn fs.WalkDirFunc) error {
+	entries, err := f.ReadDir(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+		if err := fn(path, entry, nil); err != nil {
+			if errors.Is(err, fs.SkipDir) && entry.IsDir() {
+				continue
+			}
+			return err
+		}
+		if entry.IsDir() {
+			if err := f.WalkDir(path, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExtractTemplates 将内置模板导出到目录，便于在此基础上定制
+// 已存在的文件默认跳过，force 为 true 时覆盖
+func ExtractTemplates(outDir string, force bool) error {
+	if outDir == "" {
+		return fmt.Errorf("请使用 --out 指定输出目录")
+	}
+
+	fmt.Printf("📦 导出内置模板到 %s...\n", outDir)
+
+	var written, skipped int
+	err := fs.WalkDir(dashboards.FS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		target := filepath.Join(outDir, filepath.FromSlash(path))
+		if !force {
+			if _, err := os.Stat(target); err == nil {
+				fmt.Printf("⚠️  %s 已存在，跳过\n", target)
+				skipped++
+				return nil
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+
+		data, err := fs.ReadFile(dashboards.FS, path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %w", err)
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", target, err)
+		}
+		written++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("导出内置模板失败: %w", err)
+	}
+
+	fmt.Printf("✅ 已导出 %d 个文件", written)
+	if skipped > 0 {
+		fmt.Printf("，跳过 %d 个已存在的文件（使用 --force 覆盖）", skipped)
+	}
+	fmt.Println()
+	fmt.Printf("💡 提示：将配置中的 dashboards.dir 指向 %s 即可使用导出的模板\n", outDir)
+	return nil
+}
@@ -1,353 +1,469 @@
 package dashboard
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"text/template"
+
+	"tunnel-monitor/internal/config"
+)
+
+// 模板条件使用 {% %} 作为定界符，{{ }} 已用于占位符和 Grafana 的 legendFormat
+const (
+	templateLeftDelim  = "{%"
+	templateRightDelim = "%}"
 )
 
+// templateData 基础模板、panels片段和面板清单中的模板条件可以使用的数据
+type templateData struct {
+	Features map[string]bool
+}
+
 // TemplateManager 管理 dashboard 模板的加载和组合
 type TemplateManager struct {
 	baseDir string
+	files   *templateFiles
+	sources map[string]string // 已读取的模板文件路径 -> 内容的 sha256
+
+	// Lenient 宽松模式：跳过无法加载的panels片段并输出警告
+	// 默认为严格模式，遇到第一个无法加载的片段即返回错误
+	Lenient bool
+
+	// Features 渲染模板条件时使用的功能开关，默认为配置中的 features
+	Features map[string]bool
+
+	// Env 环境名称，组合后应用 overlays/<Env> 中的覆盖配置，为空时不应用
+	Env string
+
+	// ASCIIFileNames 拆分时新建的片段使用 ASCII 文件名（panel-<ID>-<标题中的字母数字>），默认使用标题
+	ASCIIFileNames bool
 }
 
 // NewTemplateManager 创建新的模板管理器
 func NewTemplateManager(baseDir string) *TemplateManager {
 	return &TemplateManager{
-		baseDir: baseDir,
+		baseDir:  baseDir,
+		files:    openTemplateFiles(baseDir),
+		sources:  make(map[string]string),
+		Features: config.Global.Features,
 	}
 }
 
-// LoadTemplateWithPanels 加载基础模板并组合panels片段
-func (tm *TemplateManager) LoadTemplateWithPanels(baseTemplatePath string, panelDirs ...string) (map[string]interface{}, error) {
-	// 加载基础模板
-	dashboard, err := tm.loadBaseTemplate(baseTemplatePath)
-	if err != nil {
-		return nil, fmt.Errorf("加载基础模板失败: %w", err)
+// Sources 返回本次组合读取过的模板文件及其 sha256，按路径排序
+func (tm *TemplateManager) Sources() []string {
+	var sources []string
+	for path, sum := range tm.sources {
+		sources = append(sources, fmt.Sprintf("%s@%s", path, sum[:8]))
 	}
+	sort.Strings(sources)
+	return sources
+}
 
-	// 加载并组合panels
-	panels, err := tm.loadPanels(panelDirs...)
-	if err != nil {
-		return nil, fmt.Errorf("加载panels失败: %w", err)
+// SourceDigest 返回所有已读取模板文件的组合摘要，模板内容任意变化都会改变摘要
+func (tm *TemplateManager) SourceDigest() string {
+	h := sha256.New()
+	for _, source := range tm.Sources() {
+		h.Write([]byte(source))
+		h.Write([]byte{'\n'})
 	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
 
-	// 如果加载了panels，替换原有的panels
-	if len(panels) > 0 {
-		dashboard["panels"] = panels
+// readFile 读取模板文件并记录其 sha256
+func (tm *TemplateManager) readFile(path string) ([]byte, error) {
+	data, err := tm.files.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	return dashboard, nil
+	sum := sha256.Sum256(data)
+	tm.sources[filepath.Clean(path)] = hex.EncodeToString(sum[:])
+	return data, nil
 }
 
-// LoadBusinessTemplateWithPanels 加载业务监控模板并组合客户端panels
-// 只包含客户端功能业务相关的panels
-func (tm *TemplateManager) LoadBusinessTemplateWithPanels(baseTemplatePath string, clientPanelsDir string) (map[string]interface{}, error) {
-	// 加载基础模板
-	dashboard, err := tm.loadBaseTemplate(baseTemplatePath)
-	if err != nil {
-		return nil, fmt.Errorf("加载基础模板失败: %w", err)
+// loadBaseTemplate 加载基础模板（不包含panels或包含基础panels）
+func (tm *TemplateManager) loadBaseTemplate(templatePath string) (*Dashboard, error) {
+	if templatePath == "" {
+		return nil, fmt.Errorf("模板路径不能为空")
 	}
 
-	var allPanels []interface{}
-
-	// 添加客户端指标行标题
-	clientRow := map[string]interface{}{
-		"collapsed": false,
-		"gridPos": map[string]interface{}{
-			"h": 1,
-			"w": 24,
-			"x": 0,
-			"y": 0,
-		},
-		"id":     20,
-		"panels": []interface{}{},
-		"title":  "客户端指标",
-		"type":   "row",
+	data, err := tm.readFile(tm.resolvePath(templatePath))
+	if err != nil {
+		return nil, fmt.Errorf("读取模板文件失败: %w", err)
 	}
-	allPanels = append(allPanels, clientRow)
 
-	// 加载客户端panels
-	clientPanels, err := tm.loadPanelsFromDir(tm.resolvePath(clientPanelsDir))
-	if err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("加载客户端panels失败: %w", err)
+	data, err = renderTemplate(tm.resolvePath(templatePath), data, tm.Features)
+	if err != nil {
+		return nil, err
 	}
-	allPanels = append(allPanels, clientPanels...)
 
-	// 替换原有的panels
-	dashboard["panels"] = allPanels
+	dashboard, err := ParseDashboard(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析模板文件失败: %w", jsonFileError(tm.resolvePath(templatePath), data, err))
+	}
 
 	return dashboard, nil
 }
 
-// LoadServerMonitoringTemplateWithPanels 加载服务端监控模板并组合panels
-// 包含服务端监控概览和服务端业务统计两个row
-func (tm *TemplateManager) LoadServerMonitoringTemplateWithPanels(baseTemplatePath string, serverPanelsDir string) (map[string]interface{}, error) {
-	// 加载基础模板
-	dashboard, err := tm.loadBaseTemplate(baseTemplatePath)
-	if err != nil {
-		return nil, fmt.Errorf("加载基础模板失败: %w", err)
-	}
-
-	var allPanels []interface{}
-
-	// 添加服务端监控概览行标题
-	overviewRow := map[string]interface{}{
-		"collapsed": false,
-		"gridPos": map[string]interface{}{
-			"h": 1,
-			"w": 24,
-			"x": 0,
-			"y": 0,
-		},
-		"id":     1,
-		"panels": []interface{}{},
-		"title":  "服务端监控概览",
-		"type":   "row",
-	}
-	allPanels = append(allPanels, overviewRow)
-
-	// 加载服务端监控概览panels（前几个panel）
-	serverOverviewPanels, err := tm.loadServerOverviewPanels(tm.resolvePath(serverPanelsDir))
-	if err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("加载服务端监控概览panels失败: %w", err)
-	}
-	allPanels = append(allPanels, serverOverviewPanels...)
-
-	// 添加业务统计行标题
-	businessRow := map[string]interface{}{
-		"collapsed": false,
-		"gridPos": map[string]interface{}{
-			"h": 1,
-			"w": 24,
-			"x": 0,
-			"y": 13,
-		},
-		"id":     6,
-		"panels": []interface{}{},
-		"title":  "业务统计",
-		"type":   "row",
-	}
-	allPanels = append(allPanels, businessRow)
-
-	// 加载业务统计panels
-	businessPanels, err := tm.loadBusinessPanels(tm.resolvePath(serverPanelsDir))
-	if err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("加载业务统计panels失败: %w", err)
-	}
-	allPanels = append(allPanels, businessPanels...)
-
-	// 替换原有的panels
-	dashboard["panels"] = allPanels
+// tagSource 在片段加载出的 panel 上记录来源文件，文件中是 panel 数组时追加序号区分
+func tagSource(panels []*Panel, filePath string) []*Panel {
+	for i, p := range panels {
+		source := filePath
+		if len(panels) > 1 {
+			source = fmt.Sprintf("%s#%d", filePath, i)
+		}
+		p.setExtra(sourceField, source)
+	}
 
-	return dashboard, nil
+	return panels
 }
 
-// loadServerOverviewPanels 加载服务端监控概览相关的panels
-func (tm *TemplateManager) loadServerOverviewPanels(dir string) ([]interface{}, error) {
-	// 定义服务端监控概览的panel文件名
-	overviewPanelNames := []string{
-		"服务端软件版本号.json",
-		"服务端健康状态.json",
-		"POP端与服务端通信状态.json",
-		"服务端到POP延迟.json",
+// loadPanelFromFile 从文件加载单个或一组panel
+// 模板条件渲染后内容为空时返回 nil，表示当前部署不包含该片段
+func (tm *TemplateManager) loadPanelFromFile(filePath string) ([]*Panel, error) {
+	data, err := tm.readFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取panel文件失败: %w", err)
 	}
 
-	return tm.loadSpecificPanels(dir, overviewPanelNames)
-}
+	data, err = renderTemplate(filePath, data, tm.Features)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
 
-// loadBusinessPanels 加载业务统计相关的panels
-func (tm *TemplateManager) loadBusinessPanels(dir string) ([]interface{}, error) {
-	// 定义业务统计的panel文件名
-	businessPanelNames := []string{
-		"各用户订单个数.json",
+	// 文件内容可以是单个 panel 或 panel 数组
+	var panels []*Panel
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		err = json.Unmarshal(data, &panels)
+	} else {
+		panel := &Panel{}
+		err = json.Unmarshal(data, panel)
+		panels = []*Panel{panel}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析panel文件失败: %w", jsonFileError(filePath, data, err))
 	}
 
-	return tm.loadSpecificPanels(dir, businessPanelNames)
+	return panels, nil
 }
 
-// loadSpecificPanels 从目录加载指定的panel文件
-func (tm *TemplateManager) loadSpecificPanels(dir string, panelNames []string) ([]interface{}, error) {
-	var panels []interface{}
+// renderTemplate 渲染文件中 {% %} 包围的 text/template 条件，例如 {% if .Features.DNS %}
+// 不包含模板条件的文件原样返回；引用未配置的功能时返回错误
+func renderTemplate(path string, data []byte, features map[string]bool) ([]byte, error) {
+	if !bytes.Contains(data, []byte(templateLeftDelim)) {
+		return data, nil
+	}
 
-	for _, name := range panelNames {
-		fullPath := filepath.Join(dir, name)
-		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-			// 如果文件不存在，跳过
-			continue
-		}
+	t, err := template.New(path).Delims(templateLeftDelim, templateRightDelim).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("解析模板条件失败: %w", err)
+	}
 
-		data, err := os.ReadFile(fullPath)
-		if err != nil {
-			return nil, fmt.Errorf("读取panel文件 %s 失败: %w", fullPath, err)
-		}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, templateData{Features: features}); err != nil {
+		return nil, fmt.Errorf("渲染模板条件失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
 
-		var panel interface{}
-		if err := json.Unmarshal(data, &panel); err != nil {
-			return nil, fmt.Errorf("解析panel文件 %s 失败: %w", fullPath, err)
-		}
+// isTemplateFile 判断文件是否包含模板条件，这类文件不能被拉取结果直接覆盖
+func isTemplateFile(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && bytes.Contains(data, []byte(templateLeftDelim))
+}
 
-		panels = append(panels, panel)
+// skipPanelFile 处理无法加载的panel文件：严格模式下返回错误，宽松模式下输出警告并跳过
+func (tm *TemplateManager) skipPanelFile(err error) error {
+	if !tm.Lenient {
+		return err
 	}
-
-	return panels, nil
+	fmt.Printf("⚠️  已跳过 %v\n", err)
+	return nil
 }
 
-// loadBaseTemplate 加载基础模板（不包含panels或包含基础panels）
-func (tm *TemplateManager) loadBaseTemplate(templatePath string) (map[string]interface{}, error) {
-	fullPath := tm.resolvePath(templatePath)
-	return LoadTemplate(fullPath)
+// resolvePath 解析路径（相对路径转绝对路径）
+func (tm *TemplateManager) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	if tm.baseDir != "" {
+		return filepath.Join(tm.baseDir, path)
+	}
+
+	return path
 }
 
-// loadPanels 从多个目录加载panels片段并组合
-func (tm *TemplateManager) loadPanels(panelDirs ...string) ([]interface{}, error) {
-	var allPanels []interface{}
+// SplitTemplate 将完整的模板或 Grafana 导出的 JSON 拆分成基础模板和panels片段
+// 导出文件先经过 normalizeExport 规范化；返回按原 dashboard 的 row 结构引用片段的面板清单，
+// 清单中的路径相对于 baseDir，不包含 UID，面板UID默认为清单名称，不会与导出来源的面板相同
+func (tm *TemplateManager) SplitTemplate(templatePath string, outputBase string, panelsDir string, baseDir string) (*Manifest, *SplitResult, error) {
+	// 加载完整模板
+	dashboard, err := tm.loadBaseTemplate(templatePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("加载模板失败: %w", err)
+	}
+	normalizeExport(dashboard)
+
+	// SplitDashboard 会清空 panels，先保留 row 结构
+	panels := dashboard.Panels
+	m := &Manifest{Title: dashboard.Title}
 
-	for _, dir := range panelDirs {
-		if dir == "" {
-			continue
-		}
+	result, err := tm.SplitDashboard(dashboard, outputBase, panelsDir)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		fullDir := tm.resolvePath(dir)
-		panels, err := tm.loadPanelsFromDir(fullDir)
-		if err != nil {
-			// 如果目录不存在，跳过
-			if os.IsNotExist(err) {
-				continue
+	rel := func(path string) string {
+		if r, err := filepath.Rel(baseDir, path); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return filepath.ToSlash(path)
+	}
+	m.Base = rel(outputBase)
+
+	// 文件顺序与 flattenRows 一致：展开的 row 的 panel 紧跟其后，折叠的 row 的 panel 在自身的 panels 中
+	files := result.Files
+	next := func() string {
+		file := rel(files[0])
+		files = files[1:]
+		return file
+	}
+	for _, panel := range panels {
+		switch {
+		case panel.IsRow():
+			row := ManifestRow{Title: panel.Title, ID: panel.ID, Collapsed: panel.Collapsed}
+			for range flattenRows(panel.Panels) {
+				row.Panels = append(row.Panels, next())
 			}
-			return nil, err
+			m.Rows = append(m.Rows, row)
+		case len(m.Rows) > 0:
+			last := &m.Rows[len(m.Rows)-1]
+			last.Panels = append(last.Panels, next())
+		default:
+			m.Panels = append(m.Panels, next())
 		}
-
-		allPanels = append(allPanels, panels...)
 	}
 
-	return allPanels, nil
+	return m, result, nil
 }
 
-// loadPanelsFromDir 从目录加载所有panel文件
-func (tm *TemplateManager) loadPanelsFromDir(dir string) ([]interface{}, error) {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, err
+// SplitResult 拆分 dashboard 的结果
+type SplitResult struct {
+	Files []string // 每个 panel 对应的片段文件，与 flattenRows 的顺序一致
+	Stale []string // panels 目录中未被本次写入覆盖的片段文件
+}
+
+// SplitDashboard 将 dashboard 拆分成基础模板和panels片段并写入文件
+// row 由模板管理器在组合时生成，这里只保存 row 内的 panel（包括折叠 row 中的 panel）
+// 已存在的片段按标题或ID匹配并覆盖原文件，保持文件名稳定
+func (tm *TemplateManager) SplitDashboard(dashboard *Dashboard, outputBase string, panelsDir string) (*SplitResult, error) {
+	// 提取panels
+	panels := dashboard.Panels
+	if panels == nil {
+		return nil, fmt.Errorf("模板中没有panels字段")
 	}
 
-	var panels []interface{}
+	// 创建panels目录
+	if err := os.MkdirAll(panelsDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建panels目录失败: %w", err)
+	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+	existing := tm.indexPanelFiles(panelsDir)
+	written := make(map[string]bool)
+	result := &SplitResult{}
+
+	// 保存每个panel到单独文件
+	for i, panel := range flattenRows(panels) {
+		panelFile := existing.match(panel)
+		if panelFile == "" || written[panelFile] {
+			// 获取panel标题作为文件名
+			title := fmt.Sprintf("panel_%d", i+1)
+			if tm.ASCIIFileNames {
+				title = asciiFileName(panel, i+1)
+			} else if panel.Title != "" {
+				// 清理标题作为文件名
+				title = sanitizeFileName(panel.Title)
+			}
+			panelFile = uniquePanelFile(panelsDir, title, panel, i+1, written)
 		}
+		result.Files = append(result.Files, panelFile)
 
-		// 只加载JSON文件
-		if !strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+		if isTemplateFile(panelFile) {
+			fmt.Printf("⚠️  %s 包含模板条件，未覆盖，请手动合并修改\n", panelFile)
+			written[panelFile] = true
 			continue
 		}
 
-		filePath := filepath.Join(dir, entry.Name())
-		panel, err := tm.loadPanelFromFile(filePath)
-		if err != nil {
-			// 单个文件加载失败不影响其他文件
-			continue
+		// Grafana 中没有片段的扩展字段（如 x-order、x-row），沿用原文件中的值
+		for k, v := range existing.extensions[panelFile] {
+			if _, ok := panel.Extra[k]; !ok {
+				panel.setExtra(k, v)
+			}
 		}
 
-		// 支持单个panel或panel数组
-		if panelArray, ok := panel.([]interface{}); ok {
-			panels = append(panels, panelArray...)
-		} else {
-			panels = append(panels, panel)
+		if err := writeSplitFile(panelFile, panel); err != nil {
+			return nil, fmt.Errorf("保存panel文件失败: %w", err)
 		}
+		written[panelFile] = true
 	}
 
-	return panels, nil
-}
-
-// loadPanelFromFile 从文件加载单个或一组panel
-func (tm *TemplateManager) loadPanelFromFile(filePath string) (interface{}, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
+	// 清空panels，保存基础模板
+	dashboard.Panels = []*Panel{}
+	if isTemplateFile(outputBase) {
+		fmt.Printf("⚠️  %s 包含模板条件，未覆盖，请手动合并修改\n", outputBase)
+	} else {
+		if err := os.MkdirAll(filepath.Dir(outputBase), 0755); err != nil {
+			return nil, fmt.Errorf("创建基础模板目录失败: %w", err)
+		}
+		if err := writeSplitFile(outputBase, dashboard); err != nil {
+			return nil, fmt.Errorf("保存基础模板失败: %w", err)
+		}
 	}
 
-	var panel interface{}
-	if err := json.Unmarshal(data, &panel); err != nil {
-		return nil, err
+	for _, file := range existing.files {
+		if !written[file] {
+			result.Stale = append(result.Stale, file)
+		}
 	}
 
-	return panel, nil
+	return result, nil
 }
 
-// resolvePath 解析路径（相对路径转绝对路径）
-func (tm *TemplateManager) resolvePath(path string) string {
-	if filepath.IsAbs(path) {
-		return path
+// uniquePanelFile 返回 panelsDir 中名为 name 的片段文件
+// 同名的 panel（如标题相同）已经写入该文件时依次加上 panel ID 和序号区分，避免互相覆盖
+func uniquePanelFile(panelsDir, name string, panel *Panel, index int, written map[string]bool) string {
+	panelFile := filepath.Join(panelsDir, name+".json")
+	if !written[panelFile] {
+		return panelFile
 	}
 
-	if tm.baseDir != "" {
-		return filepath.Join(tm.baseDir, path)
+	if panel.ID > 0 {
+		panelFile = filepath.Join(panelsDir, fmt.Sprintf("%s_%d.json", name, panel.ID))
 	}
-
-	return path
+	for n := 2; written[panelFile]; n++ {
+		panelFile = filepath.Join(panelsDir, fmt.Sprintf("%s_%d_%d.json", name, index, n))
+	}
+	return panelFile
 }
 
-// SplitTemplate 将完整的模板拆分成基础模板和panels片段
-// 用于初始化拆分模板文件
-func (tm *TemplateManager) SplitTemplate(templatePath string, outputBase string, panelsDir string) error {
-	// 加载完整模板
-	dashboard, err := tm.loadBaseTemplate(templatePath)
-	if err != nil {
-		return fmt.Errorf("加载模板失败: %w", err)
+// flattenRows 展开 row，返回所有非 row 的 panel
+func flattenRows(panels []*Panel) []*Panel {
+	var result []*Panel
+
+	for _, panel := range panels {
+		if panel.IsRow() {
+			// 折叠的 row 把子 panel 放在自身的 panels 字段中
+			result = append(result, flattenRows(panel.Panels)...)
+			continue
+		}
+
+		result = append(result, panel)
 	}
 
-	// 提取panels
-	panels, ok := dashboard["panels"].([]interface{})
-	if !ok {
-		return fmt.Errorf("模板中没有panels字段")
+	return result
+}
+
+// panelFileIndex 记录目录中已有片段的标题和ID，用于拆分时匹配原文件
+type panelFileIndex struct {
+	files      []string
+	byTitle    map[string]string
+	byID       map[int]string
+	extensions map[string]map[string]interface{} // 片段文件 -> x- 开头的扩展字段
+}
+
+// indexPanelFiles 扫描目录中已有的panel片段
+func (tm *TemplateManager) indexPanelFiles(dir string) *panelFileIndex {
+	index := &panelFileIndex{
+		byTitle:    make(map[string]string),
+		byID:       make(map[int]string),
+		extensions: make(map[string]map[string]interface{}),
 	}
 
-	// 创建panels目录
-	if err := os.MkdirAll(panelsDir, 0755); err != nil {
-		return fmt.Errorf("创建panels目录失败: %w", err)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return index
 	}
 
-	// 保存每个panel到单独文件
-	for i, panel := range panels {
-		panelMap, ok := panel.(map[string]interface{})
-		if !ok {
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
 			continue
 		}
 
-		// 获取panel标题作为文件名
-		title := fmt.Sprintf("panel_%d", i+1)
-		if panelTitle, ok := panelMap["title"].(string); ok && panelTitle != "" {
-			// 清理标题作为文件名
-			title = sanitizeFileName(panelTitle)
-		}
+		filePath := filepath.Join(dir, entry.Name())
+		index.files = append(index.files, filePath)
 
-		panelFile := filepath.Join(panelsDir, fmt.Sprintf("%s.json", title))
-		panelData, err := json.MarshalIndent(panelMap, "", "  ")
-		if err != nil {
-			return fmt.Errorf("序列化panel失败: %w", err)
+		panels, err := tm.loadPanelFromFile(filePath)
+		if err != nil || len(panels) != 1 {
+			continue
 		}
+		panel := panels[0]
 
-		if err := os.WriteFile(panelFile, panelData, 0644); err != nil {
-			return fmt.Errorf("保存panel文件失败: %w", err)
+		if panel.Title != "" {
+			index.byTitle[panel.Title] = filePath
+		}
+		if panel.ID != 0 {
+			index.byID[panel.ID] = filePath
+		}
+		for k, v := range panel.Extra {
+			if strings.HasPrefix(k, "x-") {
+				if index.extensions[filePath] == nil {
+					index.extensions[filePath] = make(map[string]interface{})
+				}
+				index.extensions[filePath][k] = v
+			}
 		}
 	}
 
-	// 移除panels，保存基础模板
-	delete(dashboard, "panels")
-	baseData, err := json.MarshalIndent(dashboard, "", "  ")
-	if err != nil {
-		return fmt.Errorf("序列化基础模板失败: %w", err)
+	return index
+}
+
+// match 返回与 panel 对应的已有片段文件，优先按标题匹配
+func (idx *panelFileIndex) match(panel *Panel) string {
+	if file, ok := idx.byTitle[panel.Title]; ok {
+		return file
+	}
+	if panel.ID != 0 {
+		return idx.byID[panel.ID]
+	}
+	return ""
+}
+
+// asciiWordPattern 标题中的 ASCII 字母数字
+var asciiWordPattern = regexp.MustCompile(`[A-Za-z0-9]+`)
+
+// asciiFileName 生成只包含小写字母、数字和 - 的文件名：panel-<ID>，标题中有字母数字时追加在后面
+// 没有 ID 时使用 panel 的序号
+func asciiFileName(panel *Panel, index int) string {
+	id := panel.ID
+	if id <= 0 {
+		id = index
 	}
 
-	if err := os.WriteFile(outputBase, baseData, 0644); err != nil {
-		return fmt.Errorf("保存基础模板失败: %w", err)
+	var words []string
+	for _, word := range asciiWordPattern.FindAllString(panel.Title, -1) {
+		words = append(words, strings.ToLower(word))
 	}
 
-	return nil
+	name := fmt.Sprintf("panel-%d", id)
+	if len(words) > 0 {
+		name += "-" + strings.Join(words, "-")
+	}
+	if len(name) > 50 {
+		name = strings.TrimRight(name[:50], "-")
+	}
+	return name
 }
 
 // sanitizeFileName 清理文件名，移除不合法字符
@@ -364,9 +480,9 @@ func sanitizeFileName(name string) string {
 	name = strings.ReplaceAll(name, ">", "_")
 	name = strings.ReplaceAll(name, "|", "_")
 
-	// 限制长度
-	if len(name) > 50 {
-		name = name[:50]
+	// 限制长度，按字符截断，避免截断中文字符
+	if runes := []rune(name); len(runes) > 50 {
+		name = string(runes[:50])
 	}
 
 	return name
@@ -1,5 +1,13 @@
 package dashboard
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
 // getString 从 map 中安全获取字符串值
 func getString(m map[string]interface{}, key string) string {
 	if val, ok := m[key].(string); ok {
@@ -7,3 +15,55 @@ func getString(m map[string]interface{}, key string) string {
 	}
 	return ""
 }
+
+// writeJSONFile 以4空格缩进写入 JSON 文件，与仓库中的模板格式保持一致
+func writeJSONFile(path string, v interface{}) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, bytes.TrimRight(buf.Bytes(), "\n"), 0644)
+}
+
+// jsonFileError 为 JSON 解析错误加上文件路径和出错位置（行:列）
+func jsonFileError(path string, data []byte, err error) error {
+	line, col, ok := jsonErrorPosition(data, err)
+	if !ok {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return fmt.Errorf("%s:%d:%d: %w", path, line, col, err)
+}
+
+// jsonErrorPosition 根据 encoding/json 返回的偏移量计算出错的行号和列号（从 1 开始，列按字符计算）
+func jsonErrorPosition(data []byte, err error) (int, int, bool) {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return 0, 0, false
+	}
+
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	line, col := 1, 1
+	for _, r := range string(data[:offset]) {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col, true
+}
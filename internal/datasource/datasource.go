@@ -0,0 +1,127 @@
+package datasource
+
+import (
+	"fmt"
+
+	"tunnel-monitor/internal/config"
+	"tunnel-monitor/internal/grafana"
+)
+
+// Definition 描述一个需要在 Grafana 中存在的数据源
+// 字段与 Grafana 数据源 API 的请求体及 provisioning 文件格式保持一致
+type Definition struct {
+	UID            string                 `json:"uid" yaml:"uid"`
+	Name           string                 `json:"name" yaml:"name"`
+	Type           string                 `json:"type" yaml:"type"`
+	Access         string                 `json:"access" yaml:"access"`
+	URL            string                 `json:"url" yaml:"url"`
+	User           string                 `json:"user,omitempty" yaml:"user,omitempty"`
+	Database       string                 `json:"database,omitempty" yaml:"database,omitempty"`
+	IsDefault      bool                   `json:"isDefault" yaml:"isDefault"`
+	JSONData       map[string]interface{} `json:"jsonData,omitempty" yaml:"jsonData,omitempty"`
+	SecureJSONData map[string]string      `json:"secureJsonData,omitempty" yaml:"secureJsonData,omitempty"`
+}
+
+// Definitions 根据配置生成 Prometheus 和 MySQL 数据源定义，未启用 features.MySQL 时不包含 MySQL
+// UID 固定为 grafana.prometheus_uid 和 mysql.uid，与模板中的占位符替换结果一致
+func Definitions() []Definition {
+	cfg := config.Global
+
+	prometheus := Definition{
+		UID:       cfg.Grafana.PrometheusUID,
+		Name:      "Prometheus",
+		Type:      "prometheus",
+		Access:    "proxy",
+		URL:       cfg.Prometheus.URL,
+		IsDefault: true,
+		JSONData: map[string]interface{}{
+			"httpMethod": "POST",
+		},
+	}
+
+	if !cfg.Features["MySQL"] {
+		return []Definition{prometheus}
+	}
+
+	mysql := Definition{
+		UID:      cfg.MySQL.UID,
+		Name:     "MySQL",
+		Type:     "mysql",
+		Access:   "proxy",
+		URL:      fmt.Sprintf("%s:%d", cfg.MySQL.Host, cfg.MySQL.Port),
+		User:     cfg.MySQL.Username,
+		Database: cfg.MySQL.Database,
+		JSONData: map[string]interface{}{
+			"database": cfg.MySQL.Database,
+		},
+	}
+	if cfg.MySQL.Password != "" {
+		mysql.SecureJSONData = map[string]string{
+			"password": cfg.MySQL.Password,
+		}
+	}
+
+	return []Definition{prometheus, mysql}
+}
+
+// Sync 在 Grafana 中创建或更新所有数据源
+func Sync() error {
+	fmt.Println("🔗 同步 Grafana 数据源...")
+
+	client := grafana.NewClient()
+
+	for _, def := range Definitions() {
+		if def.UID == "" {
+			return fmt.Errorf("数据源 %s 未配置UID", def.Name)
+		}
+
+		created, err := upsert(client, def)
+		if err != nil {
+			return fmt.Errorf("同步数据源 %s (uid=%s) 失败: %w", def.Name, def.UID, err)
+		}
+
+		if created {
+			fmt.Printf("✅ 已创建数据源 %s (uid=%s)\n", def.Name, def.UID)
+		} else {
+			fmt.Printf("✅ 已更新数据源 %s (uid=%s)\n", def.Name, def.UID)
+		}
+	}
+
+	return nil
+}
+
+// upsert 按UID创建或更新数据源，返回是否为新建
+func upsert(client *grafana.Client, def Definition) (bool, error) {
+	var existing struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	err := client.Get("/api/datasources/uid/"+def.UID, &existing)
+	if err != nil && !grafana.IsNotFound(err) {
+		return false, err
+	}
+
+	if grafana.IsNotFound(err) {
+		// 同名但UID不同的数据源会导致创建失败，提前给出明确提示
+		var byName struct {
+			UID string `json:"uid"`
+		}
+		if err := client.Get("/api/datasources/name/"+def.Name, &byName); err == nil {
+			return false, fmt.Errorf("已存在同名数据源 %s (uid=%s)，请删除或重命名后重试", def.Name, byName.UID)
+		}
+
+		if err := client.Post("/api/datasources", def, nil); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	// 保留 Grafana 中现有的名称，只同步连接信息
+	def.Name = existing.Name
+	if err := client.Put("/api/datasources/uid/"+def.UID, def, nil); err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
@@ -0,0 +1,151 @@
+package grafana
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"tunnel-monitor/internal/config"
+)
+
+// Client 封装对 Grafana HTTP API 的调用，所有请求共用同一套认证逻辑
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	auth       func(req *http.Request)
+}
+
+// APIError 表示 Grafana 返回了非 2xx 状态码
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s 失败: %s - %s", e.Method, e.Path, e.Status, strings.TrimSpace(e.Body))
+}
+
+// IsNotFound 判断错误是否为 Grafana 返回的 404
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// NewClient 根据全局配置创建 Grafana 客户端
+// 认证优先级：service_account_token > api_key > 用户名密码（Basic Auth）
+func NewClient() *Client {
+	cfg := config.Global
+
+	c := &Client{
+		baseURL:    strings.TrimRight(cfg.Grafana.URL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	switch {
+	case cfg.Grafana.ServiceAccountToken != "":
+		c.auth = bearerAuth(cfg.Grafana.ServiceAccountToken)
+	case cfg.Grafana.APIKey != "":
+		c.auth = bearerAuth(cfg.Grafana.APIKey)
+	default:
+		username, password := cfg.Grafana.Username, cfg.Grafana.Password
+		c.auth = func(req *http.Request) {
+			req.SetBasicAuth(username, password)
+		}
+	}
+
+	return c
+}
+
+func bearerAuth(token string) func(req *http.Request) {
+	return func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// Get 发送 GET 请求，并将响应 JSON 解析到 out（out 为 nil 时忽略响应体）
+func (c *Client) Get(path string, out interface{}) error {
+	return c.Do(http.MethodGet, path, nil, out)
+}
+
+// Post 发送 POST 请求
+func (c *Client) Post(path string, body, out interface{}) error {
+	return c.Do(http.MethodPost, path, body, out)
+}
+
+// Put 发送 PUT 请求
+func (c *Client) Put(path string, body, out interface{}) error {
+	return c.Do(http.MethodPut, path, body, out)
+}
+
+// Patch 发送 PATCH 请求
+func (c *Client) Patch(path string, body, out interface{}) error {
+	return c.Do(http.MethodPatch, path, body, out)
+}
+
+// Delete 发送 DELETE 请求
+func (c *Client) Delete(path string) error {
+	return c.Do(http.MethodDelete, path, nil, nil)
+}
+
+// Do 发送请求到 Grafana API
+// body 会被序列化为 JSON；响应状态码不是 2xx 时返回 *APIError
+func (c *Client) Do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.auth(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       string(data),
+		}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("解析 %s %s 响应失败: %w", method, path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package grafana
+
+import "fmt"
+
+// Folder Grafana 文件夹
+type Folder struct {
+	UID   string `json:"uid"`
+	Title string `json:"title"`
+}
+
+// ListFolders 获取所有文件夹
+func (c *Client) ListFolders() ([]Folder, error) {
+	var folders []Folder
+	if err := c.Get("/api/folders?limit=1000", &folders); err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// EnsureFolder 按标题查找文件夹，不存在时创建，返回文件夹UID
+// 标题为空表示 General，返回空UID
+func (c *Client) EnsureFolder(title string) (string, error) {
+	if title == "" {
+		return "", nil
+	}
+
+	folders, err := c.ListFolders()
+	if err != nil {
+		return "", fmt.Errorf("获取文件夹列表失败: %w", err)
+	}
+
+	for _, folder := range folders {
+		if folder.Title == title {
+			return folder.UID, nil
+		}
+	}
+
+	var created Folder
+	if err := c.Post("/api/folders", map[string]string{"title": title}, &created); err != nil {
+		return "", fmt.Errorf("创建文件夹 %s 失败: %w", title, err)
+	}
+
+	fmt.Printf("📁 已创建文件夹 %s\n", title)
+	return created.UID, nil
+}
@@ -89,6 +89,35 @@ func UpdateConfig() error {
 		fmt.Println("✅ 已更新服务端配置")
 	}
 
+	// 启用 MySQL 时抓取 mysqld_exporter，数据库监控面板的 QPS 和慢查询使用其中的指标
+	if cfg.Features["MySQL"] && cfg.MySQL.ExporterAddress != "" {
+		mysqldCfg := ScrapeConfig{
+			JobName: "mysqld",
+			StaticConfigs: []StaticConfig{
+				{
+					Targets: []string{cfg.MySQL.ExporterAddress},
+				},
+			},
+			MetricsPath: "/metrics",
+		}
+
+		mysqldJobIndex := -1
+		for i, scrapeCfg := range promCfg.ScrapeConfigs {
+			if scrapeCfg.JobName == "mysqld" {
+				mysqldJobIndex = i
+				break
+			}
+		}
+
+		if mysqldJobIndex < 0 {
+			promCfg.ScrapeConfigs = append(promCfg.ScrapeConfigs, mysqldCfg)
+			fmt.Println("✅ 已添加 mysqld_exporter 配置")
+		} else {
+			promCfg.ScrapeConfigs[mysqldJobIndex].StaticConfigs = mysqldCfg.StaticConfigs
+			fmt.Println("✅ 已更新 mysqld_exporter 配置")
+		}
+	}
+
 	// 写入配置文件
 	data, err := yaml.Marshal(promCfg)
 	if err != nil {
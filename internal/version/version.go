@@ -0,0 +1,4 @@
+package version
+
+// Version 工具版本号，构建时通过 -ldflags "-X tunnel-monitor/internal/version.Version=v1.0.0" 注入
+var Version = "dev"